@@ -3,7 +3,9 @@ package bigquery
 import (
 	"cloud.google.com/go/bigquery"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
 	"github.com/tiketdatarisal/gcp/shared"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
@@ -13,9 +15,18 @@ import (
 )
 
 type BigQuery struct {
-	ctx     context.Context
-	client  *bigquery.Client
-	service *bq.Service
+	ctx         context.Context
+	projectID   string
+	options     []option.ClientOption
+	client      *bigquery.Client
+	service     *bq.Service
+	budget      *queryBudget
+	writeClient *writeClient
+	readClient  *readClient
+	session     *querySession
+	cache       *resultCache
+	retrier     *shared.Retrier
+	pageToken   *pageTokenKey
 }
 
 // NewBigQuery return a new BigQuery client.
@@ -41,10 +52,25 @@ func NewBigQuery(ctx context.Context, projectID string, credentialFile ...string
 		return nil, fmt.Errorf(errorWrapper, ErrInitBigQueryClientFailed, err)
 	}
 
+	// Keep client options, used when other API clients are initialized lazily
+	var options []option.ClientOption
+	if len(credentialFile) > 0 {
+		options = append(options, option.WithCredentialsFile(credentialFile[0]))
+	}
+
 	return &BigQuery{
-		ctx:     ctx,
-		client:  client,
-		service: service,
+		ctx:         ctx,
+		projectID:   projectID,
+		options:     options,
+		client:      client,
+		service:     service,
+		budget:      &queryBudget{},
+		writeClient: &writeClient{},
+		readClient:  &readClient{},
+		session:     &querySession{},
+		cache:       &resultCache{},
+		retrier:     shared.NewRetrier(),
+		pageToken:   &pageTokenKey{},
 	}, nil
 }
 
@@ -53,6 +79,14 @@ func (q BigQuery) Close() {
 	if q.client != nil {
 		_ = q.client.Close()
 	}
+
+	if q.writeClient != nil {
+		q.writeClient.close()
+	}
+
+	if q.readClient != nil {
+		q.readClient.close()
+	}
 }
 
 // GetProjectNames return a list of project names.
@@ -125,12 +159,16 @@ func (q BigQuery) GetTableNames(datasetID string) (shared.StringSlice, error) {
 	return tableNames, nil
 }
 
-// CreateTable create a new table with a schema.
-func (q BigQuery) CreateTable(datasetID, tableID string, schema *bigquery.Schema) error {
+// CreateTable create a new table with a schema and options.
+// Table without schema will be created when schema is nil.
+func (q BigQuery) CreateTable(datasetID, tableID string, schema *bigquery.Schema, opts ...config.TableOptions) error {
+	meta := newTableMetadata(opts...)
+	if schema != nil {
+		meta.Schema = *schema
+	}
+
 	table := q.client.Dataset(datasetID).Table(tableID)
-	err := table.Create(q.ctx, &bigquery.TableMetadata{
-		Schema: *schema,
-	})
+	err := table.Create(q.ctx, meta)
 	if err != nil {
 		return fmt.Errorf(errorWrapper, ErrCreateTableFailed, err)
 	}
@@ -160,17 +198,67 @@ func (q BigQuery) GetTableSchema(datasetID, tableID string) (bigquery.Schema, er
 	return meta.Schema, nil
 }
 
-// InsertRows insert a new row to a table.
+// InsertRows insert a new row to a table, retried using client retry policy as described in putRows.
 func (q BigQuery) InsertRows(datasetID, tableID string, items ...bigquery.ValueSaver) error {
 	inserter := q.client.Dataset(datasetID).Table(tableID).Inserter()
-	if err := inserter.Put(q.ctx, items); err != nil {
+	if err := putRows(q.ctx, inserter, items, q.retrier.Policy(), 0); err != nil {
 		return fmt.Errorf(errorWrapper, ErrInsertRowFailed, err)
 	}
 
 	return nil
 }
 
-// GetColumnMetadata returns columns metadata.
+// savedRow is a saved row, inserted with the same values and insertID when retried.
+type savedRow struct {
+	row      map[string]bigquery.Value
+	insertID string
+}
+
+func (r savedRow) Save() (map[string]bigquery.Value, string, error) {
+	return r.row, r.insertID, nil
+}
+
+// putRows insert rows using retry policy, each attempt limited by timeout when possible.
+// Rows are saved once with a fixed insertID, generated when empty, so BigQuery deduplicates rows of a retried request.
+// Rows are not retried when a row opted out of deduplication using bigquery.NoDedupeID,
+// and PutMultiError is never retried as a whole, its rows would fail again.
+func putRows(ctx context.Context, inserter *bigquery.Inserter, items []bigquery.ValueSaver, policy shared.RetryPolicy, timeout time.Duration) error {
+	policy = shared.InitRetryPolicy(policy)
+
+	rows := make([]bigquery.ValueSaver, len(items))
+	for i, item := range items {
+		row, insertID, err := item.Save()
+		if err != nil {
+			return err
+		}
+
+		if insertID == bigquery.NoDedupeID {
+			policy.MaxAttempts = 1
+		} else if insertID == "" {
+			insertID = randomID()
+		}
+
+		rows[i] = savedRow{row: row, insertID: insertID}
+	}
+
+	isRetryable := policy.IsRetryable
+	policy.IsRetryable = func(err error) bool {
+		var multiErr bigquery.PutMultiError
+		return !errors.As(err, &multiErr) && isRetryable(err)
+	}
+
+	return policy.Do(ctx, func() error {
+		putCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			putCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		defer cancel()
+
+		return inserter.Put(putCtx, rows)
+	})
+}
+
+// GetColumnMetadata returns columns metadata, nested columns of RECORD columns are listed in Columns.
 func (q BigQuery) GetColumnMetadata(datasetID, tableID string) (Columns, error) {
 	table := q.client.Dataset(datasetID).Table(tableID)
 	meta, err := table.Metadata(q.ctx)
@@ -178,36 +266,26 @@ func (q BigQuery) GetColumnMetadata(datasetID, tableID string) (Columns, error)
 		return nil, fmt.Errorf(errorWrapper, ErrGetColumnMetadataFailed, err)
 	}
 
-	var columns Columns
-	for _, col := range meta.Schema {
-		columns = append(columns, Column{ColumnName: col.Name, DataType: string(col.Type)})
-	}
-
-	return columns, nil
+	return newColumns("", meta.Schema, false), nil
 }
 
 // DryRunQuery return number of bytes processed when succeeded.
 func (q BigQuery) DryRunQuery(query string, labels map[string]string, timeout ...time.Duration) (int64, error) {
+	return q.DryRunQueryWithParams(query, nil, labels, timeout...)
+}
+
+// DryRunQueryWithParams return number of bytes processed by a parameterized query when succeeded.
+// Use @name placeholders with named parameters, or ? placeholders with positional (unnamed) parameters.
+func (q BigQuery) DryRunQueryWithParams(query string, params config.Parameters, labels map[string]string, timeout ...time.Duration) (int64, error) {
 	if query == "" {
 		return -1, nil
 	}
 
-	ctx := q.ctx
-	var cancel context.CancelFunc
-	if len(timeout) > 0 && timeout[0] > 0 {
-		ctx, cancel = context.WithTimeout(q.ctx, timeout[0])
-		defer func() {
-			if cancel != nil {
-				cancel()
-			}
-		}()
-	}
+	ctx, cancel := q.withTimeout(timeout...)
+	defer cancel()
 
-	task := q.client.Query(query)
+	task := q.newQuery(query, params, labels)
 	task.DryRun = true
-	if labels != nil {
-		task.Labels = labels
-	}
 
 	job, err := task.Run(ctx)
 	if err != nil {
@@ -223,45 +301,26 @@ func (q BigQuery) DryRunQuery(query string, labels map[string]string, timeout ..
 
 // RunQuery return query result when succeeded.
 func (q BigQuery) RunQuery(query string, labels map[string]string, timeout ...time.Duration) (any, error) {
+	return q.RunQueryWithParams(query, nil, labels, timeout...)
+}
+
+// RunQueryWithParams return parameterized query result when succeeded.
+// Result is served from cache when enabled using EnableCache.
+// Use @name placeholders with named parameters, or ? placeholders with positional (unnamed) parameters.
+func (q BigQuery) RunQueryWithParams(query string, params config.Parameters, labels map[string]string, timeout ...time.Duration) (any, error) {
 	if query == "" {
 		return -1, nil
 	}
 
-	ctx := q.ctx
-	var cancel context.CancelFunc
-	if len(timeout) > 0 && timeout[0] > 0 {
-		ctx, cancel = context.WithTimeout(q.ctx, timeout[0])
-		defer func() {
-			if cancel != nil {
-				cancel()
-			}
-		}()
-	}
-
-	task := q.client.Query(query)
-	if labels != nil {
-		task.Labels = labels
+	var result []map[string]bigquery.Value
+	var err error
+	if backend, c := q.cache.getBackend(); backend != nil {
+		result, err = q.runCachedQuery(backend, c, query, params, labels, timeout...)
+	} else {
+		result, err = q.queryRows(query, params, labels, timeout...)
 	}
-
-	queryIterator, err := task.Read(ctx)
 	if err != nil {
-		return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
-	}
-
-	type row = map[string]bigquery.Value
-	var result []row
-	for {
-		var r row
-		err = queryIterator.Next(&r)
-		if err == iterator.Done {
-			break
-		}
-
-		if err != nil {
-			return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
-		}
-
-		result = append(result, r)
+		return nil, err
 	}
 
 	return result, nil
@@ -269,140 +328,114 @@ func (q BigQuery) RunQuery(query string, labels map[string]string, timeout ...ti
 
 // RunQueryFunc query and process the query result in func.
 func (q BigQuery) RunQueryFunc(query string, labels map[string]string, f func(row map[string]bigquery.Value) error, timeout ...time.Duration) error {
+	return q.RunQueryFuncWithParams(query, nil, labels, f, timeout...)
+}
+
+// RunQueryFuncWithParams run a parameterized query and process the query result in func.
+// Use @name placeholders with named parameters, or ? placeholders with positional (unnamed) parameters.
+func (q BigQuery) RunQueryFuncWithParams(query string, params config.Parameters, labels map[string]string, f func(row map[string]bigquery.Value) error, timeout ...time.Duration) error {
 	if query == "" || f == nil {
 		return nil
 	}
 
-	ctx := q.ctx
-	var cancel context.CancelFunc
-	if len(timeout) > 0 && timeout[0] > 0 {
-		ctx, cancel = context.WithTimeout(q.ctx, timeout[0])
-		defer func() {
-			if cancel != nil {
-				cancel()
-			}
-		}()
-	}
-
-	task := q.client.Query(query)
-	if labels != nil {
-		task.Labels = labels
-	}
-
-	queryIterator, err := task.Read(ctx)
+	queryIterator, cancel, err := q.readQuery(query, params, labels, timeout...)
+	defer cancel()
 	if err != nil {
-		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+		return err
 	}
 
-	for {
-		// Get next row, when possible
-		var r map[string]bigquery.Value
-		err = queryIterator.Next(&r)
-		if err == iterator.Done {
-			break
-		}
-
-		if err != nil {
-			return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
-		}
-
-		// Break the loop when the function return iterator.Done
-		err = f(r)
-		if err == iterator.Done {
-			break
-		}
-
-		if err != nil {
-			return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
-		}
+	if err = iterateRows(queryIterator, f); err != nil {
+		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
 	}
 
 	return nil
 }
 
-// ExportToCsv query and export result to csv.
-func (q BigQuery) ExportToCsv(query string, labels map[string]string, gcsURI string, retry int, delay time.Duration, timeout ...time.Duration) error {
-	if query == "" {
-		return nil
-	}
-
-	ctx := q.ctx
-	var cancel context.CancelFunc
-	if len(timeout) > 0 && timeout[0] > 0 {
-		ctx, cancel = context.WithTimeout(q.ctx, timeout[0])
-		defer func() {
-			if cancel != nil {
-				cancel()
-			}
-		}()
+// newQuery return a query task with parameters and labels when possible.
+func (q BigQuery) newQuery(query string, params config.Parameters, labels map[string]string) *bigquery.Query {
+	task := q.client.Query(query)
+	if len(params) > 0 {
+		task.Parameters = params
 	}
 
-	task := q.client.Query(query)
 	if labels != nil {
 		task.Labels = labels
 	}
 
-	result, err := task.Run(ctx)
+	return task
+}
+
+// readQuery run a query within client budget and return an iterator over its result.
+// The returned cancel func must be called once the iterator is no longer used.
+func (q BigQuery) readQuery(query string, params config.Parameters, labels map[string]string, timeout ...time.Duration) (*bigquery.RowIterator, context.CancelFunc, error) {
+	return q.readQueryJob(q.newQuery(query, params, labels), config.RunQueryConfig{}, q.retrier.Policy(), timeout...)
+}
+
+// readQueryJob run a query job using budget options of a config and return an iterator over its result.
+// The returned cancel func must be called once the iterator is no longer used.
+func (q BigQuery) readQueryJob(task *bigquery.Query, c config.RunQueryConfig, policy shared.RetryPolicy, timeout ...time.Duration) (*bigquery.RowIterator, context.CancelFunc, error) {
+	ctx, cancel := q.withTimeout(timeout...)
+
+	job, err := q.runQueryJob(ctx, task, c, policy)
 	if err != nil {
-		return err
+		return nil, cancel, err
 	}
 
-	status, err := result.Wait(ctx)
+	queryIterator, err := job.Read(ctx)
 	if err != nil {
-		return err
-	} else if err := status.Err(); err != nil {
-		return err
+		return nil, cancel, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
 	}
 
-	config, err := result.Config()
+	return queryIterator, cancel, nil
+}
+
+// queryRows run a query within client budget and return all rows of its result.
+func (q BigQuery) queryRows(query string, params config.Parameters, labels map[string]string, timeout ...time.Duration) ([]map[string]bigquery.Value, error) {
+	queryIterator, cancel, err := q.readQuery(query, params, labels, timeout...)
+	defer cancel()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var tmpTable *bigquery.Table
-	if queryConfig, ok := config.(*bigquery.QueryConfig); ok {
-		tmpTable = queryConfig.Dst
-	}
+	return readRows(queryIterator)
+}
 
-	if tmpTable == nil {
-		return ErrTemporaryTableNotFound
+// readRows return all rows of a query result.
+func readRows(queryIterator *bigquery.RowIterator) ([]map[string]bigquery.Value, error) {
+	type row = map[string]bigquery.Value
+	var result []row
+	err := iterateRows(queryIterator, func(r row) error {
+		result = append(result, r)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
 	}
 
-	ref := bigquery.NewGCSReference(gcsURI)
-	ref.DestinationFormat = bigquery.CSV
-	ref.FieldDelimiter = commaDelimiter
+	return result, nil
+}
 
-	extractor := tmpTable.ExtractorTo(ref)
-	extractor.DisableHeader = false
-	if labels != nil {
-		extractor.Labels = labels
+// withTimeout return client context, with timeout when possible.
+func (q BigQuery) withTimeout(timeout ...time.Duration) (context.Context, context.CancelFunc) {
+	if len(timeout) > 0 && timeout[0] > 0 {
+		return context.WithTimeout(q.ctx, timeout[0])
 	}
 
-	var exportErr error
-	for {
-		exportErr := func() error {
-			result, err := extractor.Run(ctx)
-			if err != nil {
-				return err
-			}
-
-			status, err := result.Wait(ctx)
-			if err != nil {
-				return err
-			} else if err := status.Err(); err != nil {
-				return err
-			}
-
-			return nil
-		}()
-
-		if exportErr != nil && retry > 0 {
-			time.Sleep(delay)
-			retry--
-		} else {
-			break
-		}
+	return q.ctx, func() {}
+}
+
+// ExportToCsv query and export result to csv.
+func (q BigQuery) ExportToCsv(query string, labels map[string]string, gcsURI string, retry int, delay time.Duration, timeout ...time.Duration) error {
+	c := config.InitRunQueryConfig(config.RunQueryConfig{
+		Labels: labels,
+		Retry:  retry,
+		Delay:  delay,
+		Format: bigquery.CSV,
+	})
+
+	if len(timeout) > 0 && timeout[0] > 0 {
+		c.Timeout = timeout[0]
 	}
 
-	return exportErr
+	return q.RunQueryToGCS(query, gcsURI, c)
 }
@@ -0,0 +1,173 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"github.com/tiketdatarisal/gcp/shared"
+	"sync"
+	"time"
+)
+
+// QueryBudgetExceededError is returned when a query would exceed the bytes budget.
+// It matches ErrQueryBudgetExceeded when checked using errors.Is.
+type QueryBudgetExceededError struct {
+	// EstimatedBytes number of bytes estimated by dry run, -1 when the query was not dry run.
+	EstimatedBytes int64
+
+	// LimitBytes the exceeded limit, either max bytes billed per query or daily bytes billed.
+	LimitBytes int64
+
+	// BilledBytes number of bytes billed by the client today before the query.
+	BilledBytes int64
+}
+
+func (e *QueryBudgetExceededError) Error() string {
+	return fmt.Sprintf("%v: estimated %d bytes, billed %d bytes, limit %d bytes",
+		ErrQueryBudgetExceeded, e.EstimatedBytes, e.BilledBytes, e.LimitBytes)
+}
+
+func (e *QueryBudgetExceededError) Unwrap() error {
+	return ErrQueryBudgetExceeded
+}
+
+// queryBudget keep budget config and running total of bytes billed of a client.
+type queryBudget struct {
+	mutex  sync.Mutex
+	config config.BudgetConfig
+	day    string
+	billed int64
+}
+
+// reset running total when the day has changed, must be called while locked.
+func (b *queryBudget) reset() {
+	today := time.Now().UTC().Format(dateLayout)
+	if b.day != today {
+		b.day = today
+		b.billed = 0
+	}
+}
+
+// SetBudget set query cost guardrails of the client.
+func (q BigQuery) SetBudget(cfg config.BudgetConfig) {
+	if q.budget == nil {
+		return
+	}
+
+	q.budget.mutex.Lock()
+	defer q.budget.mutex.Unlock()
+
+	q.budget.config = cfg
+}
+
+// BytesBilled return number of bytes billed by all queries of the client today (UTC).
+func (q BigQuery) BytesBilled() int64 {
+	if q.budget == nil {
+		return 0
+	}
+
+	q.budget.mutex.Lock()
+	defer q.budget.mutex.Unlock()
+
+	q.budget.reset()
+	return q.budget.billed
+}
+
+// ResetBytesBilled reset running total of bytes billed of the client.
+func (q BigQuery) ResetBytesBilled() {
+	if q.budget == nil {
+		return
+	}
+
+	q.budget.mutex.Lock()
+	defer q.budget.mutex.Unlock()
+
+	q.budget.day = ""
+	q.budget.reset()
+}
+
+// checkBudget apply max bytes billed to the task, and dry run the task when required.
+// Return QueryBudgetExceededError when the query would exceed the budget.
+func (q BigQuery) checkBudget(ctx context.Context, task *bigquery.Query, c config.RunQueryConfig) error {
+	if q.budget == nil {
+		return nil
+	}
+
+	q.budget.mutex.Lock()
+	q.budget.reset()
+	budget, billed := q.budget.config, q.budget.billed
+	q.budget.mutex.Unlock()
+
+	maxBytes := budget.MaxBytesBilled
+	if c.MaxBytesBilled > 0 {
+		maxBytes = c.MaxBytesBilled
+	}
+
+	if maxBytes > 0 {
+		task.MaxBytesBilled = maxBytes
+	}
+
+	if budget.DailyBytesBilled > 0 && billed >= budget.DailyBytesBilled {
+		return &QueryBudgetExceededError{EstimatedBytes: -1, LimitBytes: budget.DailyBytesBilled, BilledBytes: billed}
+	}
+
+	if !budget.DryRunFirst && !c.DryRunFirst {
+		return nil
+	}
+
+	task.DryRun = true
+	job, err := task.Run(ctx)
+	task.DryRun = false
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrDryRunQueryFailed, err)
+	} else if err = job.LastStatus().Err(); err != nil {
+		return fmt.Errorf(errorWrapper, ErrDryRunQueryFailed, err)
+	}
+
+	estimated := job.LastStatus().Statistics.TotalBytesProcessed
+	if maxBytes > 0 && estimated > maxBytes {
+		return &QueryBudgetExceededError{EstimatedBytes: estimated, LimitBytes: maxBytes, BilledBytes: billed}
+	}
+
+	if budget.DailyBytesBilled > 0 && billed+estimated > budget.DailyBytesBilled {
+		return &QueryBudgetExceededError{EstimatedBytes: estimated, LimitBytes: budget.DailyBytesBilled, BilledBytes: billed}
+	}
+
+	return nil
+}
+
+// recordBytesBilled add bytes billed by a finished query job to the running total.
+func (q BigQuery) recordBytesBilled(status *bigquery.JobStatus) {
+	if q.budget == nil || status == nil || status.Statistics == nil {
+		return
+	}
+
+	queryStats, ok := status.Statistics.Details.(*bigquery.QueryStatistics)
+	if !ok {
+		return
+	}
+
+	q.budget.mutex.Lock()
+	defer q.budget.mutex.Unlock()
+
+	q.budget.reset()
+	q.budget.billed += queryStats.TotalBytesBilled
+}
+
+// runQueryJob check the budget, run the query job and wait for it to finish.
+// The query job is retried using retry policy as described in runJob.
+func (q BigQuery) runQueryJob(ctx context.Context, task *bigquery.Query, c config.RunQueryConfig, policy shared.RetryPolicy) (*bigquery.Job, error) {
+	if err := q.checkBudget(ctx, task, c); err != nil {
+		return nil, err
+	}
+
+	job, err := q.runJob(ctx, &task.JobIDConfig, policy, func() (*bigquery.Job, error) {
+		return task.Run(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,216 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"github.com/tiketdatarisal/gcp/shared"
+	"sync"
+	"time"
+)
+
+// BufferedInserter buffer rows of a table and insert them in batches from a background goroutine.
+// Rows are flushed when number of rows or estimated size reach the limit, or when flush interval has passed.
+// Always call Close to flush the remaining rows.
+type BufferedInserter struct {
+	ctx      context.Context
+	inserter *bigquery.Inserter
+	config   config.BufferedInserterConfig
+	policy   shared.RetryPolicy
+
+	mutex  sync.Mutex
+	rows   []bufferedRow
+	size   int
+	closed bool
+
+	flushMutex sync.Mutex
+	signal     chan struct{}
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+type bufferedRow struct {
+	row  bigquery.ValueSaver
+	size int
+}
+
+// NewBufferedInserter return a new BufferedInserter bound to a table.
+func (q BigQuery) NewBufferedInserter(datasetID, tableID string, cfg ...config.BufferedInserterConfig) *BufferedInserter {
+	c := config.InitBufferedInserterConfig(cfg...)
+	b := &BufferedInserter{
+		ctx:      q.ctx,
+		inserter: q.client.Dataset(datasetID).Table(tableID).Inserter(),
+		config:   c,
+		policy:   q.retryPolicy(shared.RetryPolicy{}, c.Retry, c.Delay),
+		signal:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+// Add buffer rows to be inserted in background.
+func (b *BufferedInserter) Add(rows ...bigquery.ValueSaver) error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return ErrInserterClosed
+	}
+
+	for _, row := range rows {
+		r := bufferedRow{row: row, size: estimateRowSize(row)}
+		b.rows = append(b.rows, r)
+		b.size += r.size
+	}
+
+	full := len(b.rows) >= b.config.MaxRows || b.size >= b.config.MaxBytes
+	b.mutex.Unlock()
+
+	// Wake up background goroutine, unless it has been woken up
+	if full {
+		select {
+		case b.signal <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Flush insert all buffered rows and wait until finished.
+// Return the first error, rows that could not be inserted are reported to OnError.
+func (b *BufferedInserter) Flush() error {
+	b.flushMutex.Lock()
+	defer b.flushMutex.Unlock()
+
+	var flushErr error
+	for {
+		batch := b.take()
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := b.put(batch); err != nil && flushErr == nil {
+			flushErr = err
+		}
+	}
+
+	return flushErr
+}
+
+// Close stop the background goroutine and flush the remaining rows.
+// Rows could not be added after the inserter has been closed.
+func (b *BufferedInserter) Close() error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+
+	b.closed = true
+	b.mutex.Unlock()
+
+	close(b.done)
+	b.wg.Wait()
+
+	return b.Flush()
+}
+
+func (b *BufferedInserter) run() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.signal:
+		}
+
+		_ = b.Flush()
+	}
+}
+
+// take remove a batch of buffered rows within rows and size limit.
+func (b *BufferedInserter) take() []bufferedRow {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	n, size := 0, 0
+	for n < len(b.rows) && n < b.config.MaxRows {
+		if n > 0 && size+b.rows[n].size > b.config.MaxBytes {
+			break
+		}
+
+		size += b.rows[n].size
+		n++
+	}
+
+	batch := b.rows[:n:n]
+	b.rows = b.rows[n:]
+	b.size -= size
+
+	return batch
+}
+
+// put insert a batch of rows, the batch is retried with backoff as described in putRows.
+func (b *BufferedInserter) put(batch []bufferedRow) error {
+	rows := make([]bigquery.ValueSaver, len(batch))
+	for i, r := range batch {
+		rows[i] = r.row
+	}
+
+	err := putRows(b.ctx, b.inserter, rows, b.policy, b.config.Timeout)
+	if err == nil {
+		return nil
+	}
+
+	// Rows with errors will not succeed when retried, report them
+	var multiErr bigquery.PutMultiError
+	if errors.As(err, &multiErr) {
+		for i := range multiErr {
+			rowErr := &multiErr[i]
+			if rowErr.RowIndex >= 0 && rowErr.RowIndex < len(rows) {
+				b.report(rows[rowErr.RowIndex], rowErr)
+			}
+		}
+
+		return fmt.Errorf(errorWrapper, ErrInsertRowFailed, err)
+	}
+
+	for _, row := range rows {
+		b.report(row, err)
+	}
+
+	return fmt.Errorf(errorWrapper, ErrInsertRowFailed, err)
+}
+
+func (b *BufferedInserter) report(row bigquery.ValueSaver, err error) {
+	if b.config.OnError != nil {
+		b.config.OnError(row, err)
+	}
+}
+
+// estimateRowSize return estimated size of a row in bytes, using its JSON representation.
+func estimateRowSize(row bigquery.ValueSaver) int {
+	values, _, err := row.Save()
+	if err != nil {
+		return 0
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return 0
+	}
+
+	return len(data)
+}
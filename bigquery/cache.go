@@ -0,0 +1,669 @@
+package bigquery
+
+import (
+	"bytes"
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	gcs "cloud.google.com/go/storage"
+	"golang.org/x/sync/singleflight"
+)
+
+func init() {
+	// Concrete types of query result values, required to encode cached results
+	gob.Register(map[string]bigquery.Value{})
+	gob.Register([]bigquery.Value{})
+	gob.Register(time.Time{})
+	gob.Register(civil.Date{})
+	gob.Register(civil.Time{})
+	gob.Register(civil.DateTime{})
+	gob.Register(&big.Rat{})
+	gob.Register(&bigquery.IntervalValue{})
+}
+
+// CacheBackend store cached query results, it must be safe for concurrent use.
+// Use NewMemoryCache or NewStorageCache to create a backend.
+type CacheBackend interface {
+	// Get return value of a key, false when the key is missing or expired.
+	Get(key string) ([]byte, bool, error)
+
+	// Set store value of a key, expiring after ttl.
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// Delete remove a key, missing key is not an error.
+	Delete(key string) error
+}
+
+// resultCache is query result cache of a client, shared by all copies of a BigQuery client.
+type resultCache struct {
+	mutex   sync.RWMutex
+	backend CacheBackend
+	config  config.CacheConfig
+
+	// keys of cached results by referenced table, and last invalidation time of tables.
+	// Invalidation time is kept in the backend as well, so it is shared by other clients using the same backend,
+	// remote is invalidation time last read from the backend.
+	// Keys are kept with their expiry time, and expired ones are pruned at most once per TTL.
+	keys        map[string]map[string]time.Time
+	invalidated map[string]time.Time
+	remote      map[string]remoteInvalidation
+	prunedAt    time.Time
+
+	group singleflight.Group
+}
+
+// cacheEntry is an encoded cached query result.
+type cacheEntry struct {
+	CreatedAt time.Time
+	Tables    []string
+	Rows      []map[string]bigquery.Value
+}
+
+// remoteInvalidation is invalidation time of a table read from the backend.
+type remoteInvalidation struct {
+	at        time.Time
+	checkedAt time.Time
+}
+
+// EnableCache enable query result cache of RunQuery and RunQueryWithParams using a backend.
+// Results are keyed by normalized SQL, parameters and labels, and concurrent identical queries run as one job.
+// Only SELECT queries are cached and coalesced, use InvalidateCache when a referenced table is modified.
+func (q BigQuery) EnableCache(backend CacheBackend, cfg ...config.CacheConfig) {
+	if q.cache == nil {
+		return
+	}
+
+	q.cache.mutex.Lock()
+	defer q.cache.mutex.Unlock()
+
+	q.cache.backend = backend
+	q.cache.config = config.InitCacheConfig(cfg...)
+	q.cache.keys = map[string]map[string]time.Time{}
+	q.cache.invalidated = map[string]time.Time{}
+	q.cache.remote = map[string]remoteInvalidation{}
+}
+
+// DisableCache disable query result cache, results in the backend are kept.
+func (q BigQuery) DisableCache() {
+	if q.cache == nil {
+		return
+	}
+
+	q.cache.mutex.Lock()
+	defer q.cache.mutex.Unlock()
+
+	q.cache.backend = nil
+}
+
+// InvalidateCache remove cached results of queries referencing tables.
+// Table is "dataset.table" or "project.dataset.table", table of other projects must be fully qualified.
+// Invalidation time is stored in the backend, so results cached by other clients sharing the backend are invalidated too.
+func (q BigQuery) InvalidateCache(tableIDs ...string) error {
+	if q.cache == nil {
+		return nil
+	}
+
+	var names []string
+	for _, id := range tableIDs {
+		table, err := q.tableFromID(id)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, tableName(table))
+	}
+
+	// Only local state is updated while locked, the backend could be slow
+	now := time.Now()
+	var keys []string
+	q.cache.mutex.Lock()
+	backend, ttl := q.cache.backend, q.cache.config.TTL
+	if backend != nil {
+		for _, name := range names {
+			q.cache.invalidated[name] = now
+			for key := range q.cache.keys[name] {
+				keys = append(keys, key)
+			}
+
+			delete(q.cache.keys, name)
+		}
+	}
+	q.cache.mutex.Unlock()
+
+	if backend == nil {
+		return nil
+	}
+
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(now.UnixNano()))
+	for _, name := range names {
+		if err := backend.Set(invalidationKey(name), data, ttl); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		_ = backend.Delete(key)
+	}
+
+	return nil
+}
+
+// getBackend return cache backend and config, nil when cache is disabled.
+func (c *resultCache) getBackend() (CacheBackend, config.CacheConfig) {
+	if c == nil {
+		return nil, config.CacheConfig{}
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.backend, c.config
+}
+
+// get return cached rows of a key, cache errors are treated as a miss.
+func (c *resultCache) get(backend CacheBackend, key string) ([]map[string]bigquery.Value, bool) {
+	data, ok, err := backend.Get(key)
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		return nil, false
+	}
+
+	// Entries created before their tables were invalidated are stale, e.g. when kept by a shared backend
+	for _, table := range entry.Tables {
+		if !entry.CreatedAt.After(c.invalidatedAt(backend, table)) {
+			return nil, false
+		}
+	}
+
+	return entry.Rows, true
+}
+
+// invalidatedAt return last invalidation time of a table, by this client or by other clients sharing the backend.
+// Invalidation time in the backend is read at most once per InvalidationRefresh.
+func (c *resultCache) invalidatedAt(backend CacheBackend, table string) time.Time {
+	c.mutex.RLock()
+	t := c.invalidated[table]
+	remote, ok := c.remote[table]
+	refresh := c.config.InvalidationRefresh
+	c.mutex.RUnlock()
+
+	if !ok || time.Since(remote.checkedAt) >= refresh {
+		if data, found, err := backend.Get(invalidationKey(table)); err == nil {
+			remote = remoteInvalidation{at: remote.at, checkedAt: time.Now()}
+			if found && len(data) == 8 {
+				remote.at = time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+			}
+
+			c.mutex.Lock()
+			if c.remote != nil {
+				c.remote[table] = remote
+			}
+			c.mutex.Unlock()
+		}
+	}
+
+	if remote.at.After(t) {
+		t = remote.at
+	}
+
+	return t
+}
+
+// invalidationKey return backend key of last invalidation time of a table.
+// It expires after TTL, when all results cached before the invalidation have expired as well.
+func invalidationKey(table string) string {
+	return "table-" + table
+}
+
+// set store rows of a key, result larger than MaxResultBytes is not cached.
+func (c *resultCache) set(backend CacheBackend, cfg config.CacheConfig, key string, entry cacheEntry) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil || buf.Len() > cfg.MaxResultBytes {
+		return
+	}
+
+	if err := backend.Set(key, buf.Bytes(), cfg.TTL); err != nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for _, table := range entry.Tables {
+		if c.keys[table] == nil {
+			c.keys[table] = map[string]time.Time{}
+		}
+
+		c.keys[table][key] = now.Add(cfg.TTL)
+	}
+
+	if now.Sub(c.prunedAt) >= cfg.TTL {
+		c.prune(now)
+	}
+}
+
+// prune remove keys of expired results, and invalidation times older than TTL.
+// Results cached before an invalidation older than TTL have expired, so it has no effect anymore.
+// Caller must hold the lock.
+func (c *resultCache) prune(now time.Time) {
+	for table, keys := range c.keys {
+		for key, expiry := range keys {
+			if !now.Before(expiry) {
+				delete(keys, key)
+			}
+		}
+
+		if len(keys) == 0 {
+			delete(c.keys, table)
+		}
+	}
+
+	for table, at := range c.invalidated {
+		if now.Sub(at) >= c.config.TTL {
+			delete(c.invalidated, table)
+		}
+	}
+
+	for table, remote := range c.remote {
+		if now.Sub(remote.at) >= c.config.TTL && now.Sub(remote.checkedAt) >= c.config.InvalidationRefresh {
+			delete(c.remote, table)
+		}
+	}
+
+	c.prunedAt = now
+}
+
+// runCachedQuery return query result from cache, or run the query and cache its result.
+// Concurrent calls with the same key wait for a single query job.
+func (q BigQuery) runCachedQuery(backend CacheBackend, cfg config.CacheConfig, query string, params config.Parameters, labels map[string]string, timeout ...time.Duration) ([]map[string]bigquery.Value, error) {
+	key, err := cacheKey(query, params, labels)
+	if err != nil {
+		return q.queryRows(query, params, labels, timeout...)
+	}
+
+	// Statements other than SELECT have side effects, they are neither cached nor coalesced
+	if !isSelectQuery(query) {
+		return q.queryRows(query, params, labels, timeout...)
+	}
+
+	if rows, ok := q.cache.get(backend, key); ok {
+		return rows, nil
+	}
+
+	result, err, shared := q.cache.group.Do(key, func() (any, error) {
+		if rows, ok := q.cache.get(backend, key); ok {
+			return rows, nil
+		}
+
+		ctx, cancel := q.withTimeout(timeout...)
+		defer cancel()
+
+		start := time.Now()
+		job, err := q.runQueryJob(ctx, q.newQuery(query, params, labels), config.RunQueryConfig{}, q.retrier.Policy())
+		if err != nil {
+			return nil, err
+		}
+
+		queryIterator, err := job.Read(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+		}
+
+		rows, err := readRows(queryIterator)
+		if err != nil {
+			return nil, err
+		}
+
+		// Statement type is verified, e.g. a SELECT query could call a procedure
+		status := job.LastStatus()
+		if status == nil || status.Statistics == nil {
+			return rows, nil
+		}
+
+		if stats, ok := status.Statistics.Details.(*bigquery.QueryStatistics); ok && stats.StatementType == "SELECT" {
+			entry := cacheEntry{CreatedAt: start, Rows: rows}
+			for _, table := range stats.ReferencedTables {
+				entry.Tables = append(entry.Tables, tableName(table))
+			}
+
+			q.cache.set(backend, cfg, key, entry)
+		}
+
+		return rows, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows of a coalesced job are returned to every waiter, each of them gets its own copy
+	rows := result.([]map[string]bigquery.Value)
+	if shared {
+		return copyRows(rows), nil
+	}
+
+	return rows, nil
+}
+
+// copyRows return a deep copy of rows, so they could be modified without affecting other copies.
+func copyRows(rows []map[string]bigquery.Value) []map[string]bigquery.Value {
+	if rows == nil {
+		return nil
+	}
+
+	result := make([]map[string]bigquery.Value, len(rows))
+	for i, row := range rows {
+		result[i] = copyValue(row).(map[string]bigquery.Value)
+	}
+
+	return result
+}
+
+// copyValue return a deep copy of a query result value, values that are not mutable are returned as is.
+func copyValue(v bigquery.Value) bigquery.Value {
+	switch value := v.(type) {
+	case map[string]bigquery.Value:
+		if value == nil {
+			return value
+		}
+
+		result := make(map[string]bigquery.Value, len(value))
+		for k, item := range value {
+			result[k] = copyValue(item)
+		}
+
+		return result
+	case []bigquery.Value:
+		if value == nil {
+			return value
+		}
+
+		result := make([]bigquery.Value, len(value))
+		for i, item := range value {
+			result[i] = copyValue(item)
+		}
+
+		return result
+	case []byte:
+		return append([]byte(nil), value...)
+	case *big.Rat:
+		if value != nil {
+			return new(big.Rat).Set(value)
+		}
+	case *bigquery.IntervalValue:
+		if value != nil {
+			interval := *value
+			return &interval
+		}
+	}
+
+	return v
+}
+
+// cacheKey return cache key of a query with its parameters and labels.
+func cacheKey(query string, params config.Parameters, labels map[string]string) (string, error) {
+	data, err := json.Marshal(struct {
+		Query      string
+		Parameters config.Parameters
+		Labels     map[string]string
+	}{
+		Query:      normalizeQuery(query),
+		Parameters: params,
+		Labels:     labels,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeQuery collapse whitespaces outside of quoted strings and identifiers, and trim trailing semicolons.
+func normalizeQuery(query string) string {
+	var sb strings.Builder
+	var quote rune
+	var escaped, space bool
+	for _, r := range strings.TrimSpace(query) {
+		switch {
+		case quote != 0:
+			if escaped {
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		}
+
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return strings.TrimRight(sb.String(), "; ")
+}
+
+// isSelectQuery return true when a query is a single SELECT statement, optionally with WITH clause.
+// Queries starting with a comment or containing multiple statements are not considered as SELECT queries.
+func isSelectQuery(query string) bool {
+	normalized := strings.TrimLeft(normalizeQuery(query), "( ")
+	keyword := normalized
+	if i := strings.IndexFunc(normalized, func(r rune) bool { return !unicode.IsLetter(r) }); i >= 0 {
+		keyword = normalized[:i]
+	}
+
+	keyword = strings.ToUpper(keyword)
+	if keyword != "SELECT" && keyword != "WITH" {
+		return false
+	}
+
+	var quote rune
+	var escaped bool
+	for _, r := range normalized {
+		switch {
+		case quote != 0:
+			if escaped {
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ';':
+			return false
+		}
+	}
+
+	return true
+}
+
+// tableName return fully qualified name of a table, used to track cached results by table.
+func tableName(table *bigquery.Table) string {
+	return fmt.Sprintf("%s.%s.%s", table.ProjectID, table.DatasetID, table.TableID)
+}
+
+// memoryCache is an in-memory LRU CacheBackend.
+type memoryCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	size       int64
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache return an in-memory LRU CacheBackend.
+// Least recently used results are evicted when there are more than maxEntries results, or more than maxBytes in total.
+// Zero value of maxEntries or maxBytes means no limit.
+func NewMemoryCache(maxEntries int, maxBytes int64) CacheBackend {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (m *memoryCache) Get(key string) ([]byte, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		m.remove(element)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (m *memoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+
+	if m.maxBytes > 0 && int64(len(value)) > m.maxBytes {
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key: key, value: value, expires: time.Now().Add(ttl)})
+	m.size += int64(len(value))
+
+	for (m.maxEntries > 0 && m.order.Len() > m.maxEntries) || (m.maxBytes > 0 && m.size > m.maxBytes) {
+		m.remove(m.order.Back())
+	}
+
+	return nil
+}
+
+func (m *memoryCache) Delete(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+
+	return nil
+}
+
+func (m *memoryCache) remove(element *list.Element) {
+	entry := m.order.Remove(element).(*memoryCacheEntry)
+	delete(m.entries, entry.key)
+	m.size -= int64(len(entry.value))
+}
+
+// CacheStorage store files in a bucket, for example storage.Storage.
+// IsFileExists must return an error matching storage.ErrObjectNotExist of cloud.google.com/go/storage for a missing file,
+// other errors are returned by the backend.
+type CacheStorage interface {
+	FileUploader
+	IsFileExists(bucketName, fileName string) error
+	DownloadFile(bucketName, fileName string) ([]byte, error)
+	DeleteFile(bucketName, fileName string) error
+}
+
+// storageCache is a CacheBackend storing results as files in a bucket.
+type storageCache struct {
+	storage    CacheStorage
+	bucketName string
+	prefix     string
+}
+
+// NewStorageCache return a CacheBackend storing results as files with prefix in a bucket, for example using storage.Storage.
+// Expiry time is kept in the file, consider a lifecycle rule of the bucket to remove expired files.
+func NewStorageCache(storage CacheStorage, bucketName, prefix string) CacheBackend {
+	return &storageCache{storage: storage, bucketName: bucketName, prefix: prefix}
+}
+
+func (s *storageCache) Get(key string) ([]byte, bool, error) {
+	fileName := s.prefix + key
+	if err := s.storage.IsFileExists(s.bucketName, fileName); err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	data, err := s.storage.DownloadFile(s.bucketName, fileName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// First 8 bytes is expiry time in unix nanoseconds
+	if len(data) < 8 {
+		return nil, false, nil
+	}
+
+	if time.Now().UnixNano() > int64(binary.BigEndian.Uint64(data[:8])) {
+		_ = s.storage.DeleteFile(s.bucketName, fileName)
+		return nil, false, nil
+	}
+
+	return data[8:], true, nil
+}
+
+func (s *storageCache) Set(key string, value []byte, ttl time.Duration) error {
+	data := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(data, uint64(time.Now().Add(ttl).UnixNano()))
+	return s.storage.UploadFile(s.bucketName, s.prefix+key, append(data, value...))
+}
+
+func (s *storageCache) Delete(key string) error {
+	fileName := s.prefix + key
+	if err := s.storage.IsFileExists(s.bucketName, fileName); err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	return s.storage.DeleteFile(s.bucketName, fileName)
+}
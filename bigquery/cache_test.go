@@ -0,0 +1,348 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	gcs "cloud.google.com/go/storage"
+	"encoding/binary"
+	"errors"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "empty", query: "", want: ""},
+		{name: "already normalized", query: "SELECT 1", want: "SELECT 1"},
+		{name: "collapse whitespaces", query: "  SELECT\n\ta,\r\n  b   FROM t  ", want: "SELECT a, b FROM t"},
+		{name: "trim trailing semicolons", query: "SELECT 1 ; ;", want: "SELECT 1"},
+		{name: "keep single quoted string", query: "SELECT 'a  \n b'  FROM t", want: "SELECT 'a  \n b' FROM t"},
+		{name: "keep double quoted string", query: "SELECT \"a  b\"", want: "SELECT \"a  b\""},
+		{name: "keep quoted identifier", query: "SELECT *  FROM `my  table`", want: "SELECT * FROM `my  table`"},
+		{name: "escaped quote inside string", query: "SELECT 'it\\'s  here'   AS x", want: "SELECT 'it\\'s  here' AS x"},
+		{name: "semicolon inside string", query: "SELECT ';'", want: "SELECT ';'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeQuery(tt.query); got != tt.want {
+				t.Errorf("normalizeQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSelectQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "SELECT 1", want: true},
+		{query: "  select * from t;", want: true},
+		{query: "WITH a AS (SELECT 1) SELECT * FROM a", want: true},
+		{query: "(SELECT 1) UNION ALL (SELECT 2)", want: true},
+		{query: "SELECT ';' AS x", want: true},
+		{query: "SELECT 1; DELETE FROM t WHERE TRUE", want: false},
+		{query: "INSERT INTO t SELECT 1", want: false},
+		{query: "CREATE TABLE t AS SELECT 1", want: false},
+		{query: "-- comment\nSELECT 1", want: false},
+		{query: "SELECTED", want: false},
+		{query: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := isSelectQuery(tt.query); got != tt.want {
+				t.Errorf("isSelectQuery(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	params := config.Parameters{{Name: "id", Value: 1}}
+	labels := map[string]string{"team": "data"}
+	base, err := cacheKey("SELECT * FROM t WHERE id = @id", params, labels)
+	if err != nil {
+		t.Fatalf("cacheKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		params config.Parameters
+		labels map[string]string
+		same   bool
+	}{
+		{
+			name:   "same query",
+			query:  "SELECT * FROM t WHERE id = @id",
+			params: params,
+			labels: labels,
+			same:   true,
+		},
+		{
+			name:   "different whitespaces and trailing semicolon",
+			query:  "SELECT *\n  FROM t\n WHERE id = @id;",
+			params: config.Parameters{{Name: "id", Value: 1}},
+			labels: map[string]string{"team": "data"},
+			same:   true,
+		},
+		{
+			name:   "different query",
+			query:  "SELECT * FROM t WHERE id > @id",
+			params: params,
+			labels: labels,
+		},
+		{
+			name:   "different parameter value",
+			query:  "SELECT * FROM t WHERE id = @id",
+			params: config.Parameters{{Name: "id", Value: 2}},
+			labels: labels,
+		},
+		{
+			name:   "different parameter name",
+			query:  "SELECT * FROM t WHERE id = @id",
+			params: config.Parameters{{Name: "key", Value: 1}},
+			labels: labels,
+		},
+		{
+			name:   "without parameters",
+			query:  "SELECT * FROM t WHERE id = @id",
+			labels: labels,
+		},
+		{
+			name:   "different labels",
+			query:  "SELECT * FROM t WHERE id = @id",
+			params: params,
+			labels: map[string]string{"team": "other"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := cacheKey(tt.query, tt.params, tt.labels)
+			if err != nil {
+				t.Fatalf("cacheKey() error = %v", err)
+			}
+
+			if (key == base) != tt.same {
+				t.Errorf("cacheKey() same = %v, want %v", key == base, tt.same)
+			}
+		})
+	}
+
+	// Whitespaces inside strings are kept, so these queries have different keys
+	a, _ := cacheKey("SELECT 'a b'", nil, nil)
+	b, _ := cacheKey("SELECT 'a  b'", nil, nil)
+	if a == b {
+		t.Errorf("cacheKey() of queries with different strings are equal")
+	}
+
+	// Parameters that could not be encoded are reported
+	if _, err = cacheKey("SELECT @x", config.Parameters{{Name: "x", Value: make(chan int)}}, nil); err == nil {
+		t.Errorf("cacheKey() error = nil, want error for unsupported parameter value")
+	}
+}
+
+func TestCopyRows(t *testing.T) {
+	rows := []map[string]bigquery.Value{{
+		"id":      int64(1),
+		"amount":  big.NewRat(3, 2),
+		"data":    []byte{1, 2},
+		"tags":    []bigquery.Value{"a", "b"},
+		"address": map[string]bigquery.Value{"city": "Jakarta"},
+		"none":    (*big.Rat)(nil),
+	}}
+
+	copied := copyRows(rows)
+	if !reflect.DeepEqual(copied, rows) {
+		t.Fatalf("copyRows() = %v, want %v", copied, rows)
+	}
+
+	copied[0]["id"] = int64(2)
+	copied[0]["amount"].(*big.Rat).SetInt64(5)
+	copied[0]["data"].([]byte)[0] = 9
+	copied[0]["tags"].([]bigquery.Value)[0] = "z"
+	copied[0]["address"].(map[string]bigquery.Value)["city"] = "Bali"
+
+	want := []map[string]bigquery.Value{{
+		"id":      int64(1),
+		"amount":  big.NewRat(3, 2),
+		"data":    []byte{1, 2},
+		"tags":    []bigquery.Value{"a", "b"},
+		"address": map[string]bigquery.Value{"city": "Jakarta"},
+		"none":    (*big.Rat)(nil),
+	}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows after modifying the copy = %v, want %v", rows, want)
+	}
+
+	if copyRows(nil) != nil {
+		t.Errorf("copyRows(nil) != nil")
+	}
+}
+
+// countingCache is a CacheBackend counting Get calls.
+type countingCache struct {
+	CacheBackend
+	gets int
+}
+
+func (c *countingCache) Get(key string) ([]byte, bool, error) {
+	c.gets++
+	return c.CacheBackend.Get(key)
+}
+
+func TestInvalidatedAt(t *testing.T) {
+	backend := &countingCache{CacheBackend: NewMemoryCache(0, 0)}
+	cache := &resultCache{
+		config: config.InitCacheConfig(config.CacheConfig{InvalidationRefresh: time.Hour}),
+		remote: map[string]remoteInvalidation{},
+	}
+
+	if at := cache.invalidatedAt(backend, "p.d.t"); !at.IsZero() {
+		t.Errorf("invalidatedAt() = %v, want zero time", at)
+	}
+
+	// Invalidation by another client is not read again before InvalidationRefresh
+	at := time.Unix(0, time.Now().UnixNano())
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(at.UnixNano()))
+	_ = backend.Set(invalidationKey("p.d.t"), data, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if got := cache.invalidatedAt(backend, "p.d.t"); !got.IsZero() {
+			t.Errorf("invalidatedAt() = %v, want zero time", got)
+		}
+	}
+
+	if backend.gets != 1 {
+		t.Errorf("backend gets = %d, want 1", backend.gets)
+	}
+
+	cache.config.InvalidationRefresh = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if got := cache.invalidatedAt(backend, "p.d.t"); !got.Equal(at) {
+		t.Errorf("invalidatedAt() = %v, want %v", got, at)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	cache := &resultCache{
+		config: config.InitCacheConfig(),
+		keys: map[string]map[string]time.Time{
+			"p.d.a": {"expired": now.Add(-time.Second), "live": now.Add(time.Minute)},
+			"p.d.b": {"expired": now.Add(-time.Second)},
+		},
+		invalidated: map[string]time.Time{
+			"p.d.a": now.Add(-time.Hour),
+			"p.d.b": now.Add(-time.Second),
+		},
+		remote: map[string]remoteInvalidation{
+			"p.d.a": {at: now.Add(-time.Hour), checkedAt: now.Add(-time.Hour)},
+			"p.d.b": {at: now.Add(-time.Hour), checkedAt: now},
+		},
+	}
+
+	cache.prune(now)
+
+	wantKeys := map[string]map[string]time.Time{"p.d.a": {"live": now.Add(time.Minute)}}
+	if !reflect.DeepEqual(cache.keys, wantKeys) {
+		t.Errorf("keys = %v, want %v", cache.keys, wantKeys)
+	}
+
+	if _, ok := cache.invalidated["p.d.a"]; ok || len(cache.invalidated) != 1 {
+		t.Errorf("invalidated = %v, want only p.d.b", cache.invalidated)
+	}
+
+	if _, ok := cache.remote["p.d.a"]; ok || len(cache.remote) != 1 {
+		t.Errorf("remote = %v, want only p.d.b", cache.remote)
+	}
+
+	if !cache.prunedAt.Equal(now) {
+		t.Errorf("prunedAt = %v, want %v", cache.prunedAt, now)
+	}
+}
+
+// fakeStorage is a CacheStorage keeping files in memory, err is returned by every call when set.
+type fakeStorage struct {
+	files map[string][]byte
+	err   error
+}
+
+func (f *fakeStorage) UploadFile(_, fileName string, data []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.files[fileName] = data
+	return nil
+}
+
+func (f *fakeStorage) IsFileExists(_, fileName string) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	if _, ok := f.files[fileName]; !ok {
+		return gcs.ErrObjectNotExist
+	}
+
+	return nil
+}
+
+func (f *fakeStorage) DownloadFile(_, fileName string) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return f.files[fileName], nil
+}
+
+func (f *fakeStorage) DeleteFile(_, fileName string) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	delete(f.files, fileName)
+	return nil
+}
+
+func TestStorageCache(t *testing.T) {
+	storage := &fakeStorage{files: map[string][]byte{}}
+	cache := NewStorageCache(storage, "bucket", "cache/")
+
+	if _, ok, err := cache.Get("missing"); ok || err != nil {
+		t.Errorf("Get(missing) = %v, %v, want miss without error", ok, err)
+	}
+
+	if err := cache.Delete("missing"); err != nil {
+		t.Errorf("Delete(missing) = %v, want nil", err)
+	}
+
+	if err := cache.Set("key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set() = %v", err)
+	}
+
+	if value, ok, err := cache.Get("key"); !ok || err != nil || string(value) != "value" {
+		t.Errorf("Get(key) = %q, %v, %v, want value", value, ok, err)
+	}
+
+	// Errors other than a missing file are not a miss
+	storage.err = errors.New("permission denied")
+	if _, ok, err := cache.Get("key"); ok || !errors.Is(err, storage.err) {
+		t.Errorf("Get(key) = %v, %v, want error %v", ok, err, storage.err)
+	}
+
+	if err := cache.Delete("key"); !errors.Is(err, storage.err) {
+		t.Errorf("Delete(key) = %v, want error %v", err, storage.err)
+	}
+}
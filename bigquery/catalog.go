@@ -0,0 +1,129 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"fmt"
+	"google.golang.org/api/iterator"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// catalogConcurrency is max number of table metadata fetched in parallel by GetDatasetCatalog.
+const catalogConcurrency = 8
+
+// TableInfo is catalog metadata of a table, view or materialized view.
+type TableInfo struct {
+	ProjectID   string
+	DatasetID   string
+	TableID     string
+	Type        bigquery.TableType
+	Description string
+	Labels      map[string]string
+
+	NumRows          uint64
+	NumBytes         int64
+	NumLongTermBytes int64
+
+	TimePartitioning       *bigquery.TimePartitioning
+	RangePartitioning      *bigquery.RangePartitioning
+	RequirePartitionFilter bool
+	ClusteringFields       []string
+
+	CreationTime     time.Time
+	LastModifiedTime time.Time
+
+	// ExpirationTime is zero when the table never expire.
+	ExpirationTime time.Time
+
+	Columns Columns
+}
+
+// DescribeTable return catalog metadata of a table.
+func (q BigQuery) DescribeTable(datasetID, tableID string) (*TableInfo, error) {
+	return q.describeTable(q.ctx, q.client.Dataset(datasetID).Table(tableID))
+}
+
+// GetDatasetCatalog return catalog metadata of all tables in a dataset, sorted by table ID.
+func (q BigQuery) GetDatasetCatalog(datasetID string) ([]TableInfo, error) {
+	ctx, cancel := context.WithTimeout(q.ctx, timeoutDuration)
+	defer cancel()
+
+	var tables []*bigquery.Table
+	tableIterator := q.client.Dataset(datasetID).Tables(ctx)
+	for {
+		table, err := tableIterator.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf(errorWrapper, ErrDescribeTableFailed, err)
+		}
+
+		tables = append(tables, table)
+	}
+
+	// Metadata is only available per table, fetch them in parallel
+	var mutex sync.Mutex
+	catalog := make([]TableInfo, 0, len(tables))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(catalogConcurrency)
+	for _, table := range tables {
+		table := table
+		group.Go(func() error {
+			info, err := q.describeTable(groupCtx, table)
+			if err != nil {
+				return err
+			}
+
+			mutex.Lock()
+			catalog = append(catalog, *info)
+			mutex.Unlock()
+
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].TableID < catalog[j].TableID })
+	return catalog, nil
+}
+
+func (q BigQuery) describeTable(ctx context.Context, table *bigquery.Table) (*TableInfo, error) {
+	meta, err := table.Metadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrDescribeTableFailed, err)
+	}
+
+	info := &TableInfo{
+		ProjectID:              table.ProjectID,
+		DatasetID:              table.DatasetID,
+		TableID:                table.TableID,
+		Type:                   meta.Type,
+		Description:            meta.Description,
+		Labels:                 meta.Labels,
+		NumRows:                meta.NumRows,
+		NumBytes:               meta.NumBytes,
+		NumLongTermBytes:       meta.NumLongTermBytes,
+		TimePartitioning:       meta.TimePartitioning,
+		RangePartitioning:      meta.RangePartitioning,
+		RequirePartitionFilter: meta.RequirePartitionFilter,
+		CreationTime:           meta.CreationTime,
+		LastModifiedTime:       meta.LastModifiedTime,
+		ExpirationTime:         meta.ExpirationTime,
+		Columns:                newColumns("", meta.Schema, false),
+	}
+
+	if meta.Clustering != nil {
+		info.ClusteringFields = meta.Clustering.Fields
+	}
+
+	return info, nil
+}
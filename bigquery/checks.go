@@ -0,0 +1,344 @@
+package bigquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// CheckType is type of data quality check.
+type CheckType string
+
+const (
+	CheckNotNull     CheckType = "NOT_NULL"
+	CheckUnique      CheckType = "UNIQUE"
+	CheckRowCount    CheckType = "ROW_COUNT"
+	CheckFreshness   CheckType = "FRESHNESS"
+	CheckReferential CheckType = "REFERENTIAL_INTEGRITY"
+	CheckCustom      CheckType = "CUSTOM"
+)
+
+// Check is a data quality check of a table, it passes when there is no violating row.
+// Use NotNullCheck, UniqueCheck, RowCountCheck, FreshnessCheck, ReferentialCheck or CustomCheck to create a check.
+type Check struct {
+	Name      string
+	Type      CheckType
+	DatasetID string
+	TableID   string
+
+	// Columns are checked columns, key columns of UNIQUE, or foreign key columns of REFERENTIAL_INTEGRITY.
+	Columns []string
+
+	// Where is SQL filter of checked rows, for example "created_date = CURRENT_DATE()" (Optional).
+	Where string
+
+	// MinRows and MaxRows are allowed range of row count, MaxRows of 0 means no upper limit.
+	MinRows int64
+	MaxRows int64
+
+	// MaxAge is allowed age of the latest value of a TIMESTAMP, DATETIME or DATE column.
+	MaxAge time.Duration
+
+	// RefDatasetID, RefTableID and RefColumns are referenced table and its key columns.
+	RefDatasetID string
+	RefTableID   string
+	RefColumns   []string
+
+	// Query is SQL query of CUSTOM check, returning the violating rows.
+	Query string
+}
+
+// CheckResult is result of a data quality check.
+type CheckResult struct {
+	Name       string           `json:"name"`
+	Type       CheckType        `json:"type"`
+	Table      string           `json:"table,omitempty"`
+	Passed     bool             `json:"passed"`
+	Violations int64            `json:"violations"`
+	Samples    []map[string]any `json:"samples,omitempty"`
+	Query      string           `json:"query"`
+	Error      string           `json:"error,omitempty"`
+	Duration   time.Duration    `json:"duration"`
+}
+
+// CheckReport is report of data quality checks, results are in the same order as the checks.
+type CheckReport struct {
+	StartTime time.Time     `json:"startTime"`
+	EndTime   time.Time     `json:"endTime"`
+	Passed    bool          `json:"passed"`
+	Total     int           `json:"total"`
+	Failed    int           `json:"failed"`
+	Errors    int           `json:"errors"`
+	Results   []CheckResult `json:"results"`
+}
+
+// FileUploader upload data into a file in a bucket, for example storage.Storage.
+type FileUploader interface {
+	UploadFile(bucketName, fileName string, data []byte) error
+}
+
+// NotNullCheck return a check that columns of a table have no NULL value.
+func NotNullCheck(datasetID, tableID string, columns ...string) Check {
+	return Check{Type: CheckNotNull, DatasetID: datasetID, TableID: tableID, Columns: columns}
+}
+
+// UniqueCheck return a check that rows of a table are unique by key columns.
+func UniqueCheck(datasetID, tableID string, keyColumns ...string) Check {
+	return Check{Type: CheckUnique, DatasetID: datasetID, TableID: tableID, Columns: keyColumns}
+}
+
+// RowCountCheck return a check that number of rows of a table is within range, maxRows of 0 means no upper limit.
+func RowCountCheck(datasetID, tableID string, minRows, maxRows int64) Check {
+	return Check{Type: CheckRowCount, DatasetID: datasetID, TableID: tableID, MinRows: minRows, MaxRows: maxRows}
+}
+
+// FreshnessCheck return a check that the latest value of a TIMESTAMP, DATETIME or DATE column is not older than maxAge.
+func FreshnessCheck(datasetID, tableID, column string, maxAge time.Duration) Check {
+	return Check{Type: CheckFreshness, DatasetID: datasetID, TableID: tableID, Columns: []string{column}, MaxAge: maxAge}
+}
+
+// ReferentialCheck return a check that non-NULL foreign key columns of a table reference existing rows of another table.
+func ReferentialCheck(datasetID, tableID string, columns []string, refDatasetID, refTableID string, refColumns []string) Check {
+	return Check{
+		Type:         CheckReferential,
+		DatasetID:    datasetID,
+		TableID:      tableID,
+		Columns:      columns,
+		RefDatasetID: refDatasetID,
+		RefTableID:   refTableID,
+		RefColumns:   refColumns,
+	}
+}
+
+// CustomCheck return a check from SQL query returning the violating rows.
+func CustomCheck(name, query string) Check {
+	return Check{Name: name, Type: CheckCustom, Query: query}
+}
+
+// RunChecks run data quality checks in parallel and return their report.
+// The report is always returned, error is returned when a check has failed or could not be run.
+func (q BigQuery) RunChecks(checks []Check, cfg ...config.CheckConfig) (*CheckReport, error) {
+	// Get config from parameter
+	c := config.InitCheckConfig(cfg...)
+
+	report := &CheckReport{
+		StartTime: time.Now(),
+		Total:     len(checks),
+		Results:   make([]CheckResult, len(checks)),
+	}
+
+	var group errgroup.Group
+	group.SetLimit(c.Concurrency)
+	for i, check := range checks {
+		i, check := i, check
+		group.Go(func() error {
+			report.Results[i] = q.runCheck(check, c)
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+
+	report.EndTime = time.Now()
+	for _, result := range report.Results {
+		if result.Error != "" {
+			report.Errors++
+		} else if !result.Passed {
+			report.Failed++
+		}
+	}
+
+	report.Passed = report.Failed == 0 && report.Errors == 0
+	if !report.Passed {
+		return report, fmt.Errorf("%w: %d failed, %d errors of %d checks", ErrCheckFailed, report.Failed, report.Errors, report.Total)
+	}
+
+	return report, nil
+}
+
+// JSON return the report encoded as indented JSON.
+func (r CheckReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Upload upload the report as JSON into a file in a bucket, for example using storage.Storage.
+func (r CheckReport) Upload(uploader FileUploader, bucketName, fileName string) error {
+	data, err := r.JSON()
+	if err != nil {
+		return err
+	}
+
+	return uploader.UploadFile(bucketName, fileName, data)
+}
+
+// runCheck run a check, counting its violating rows and keeping samples of them.
+func (q BigQuery) runCheck(check Check, c config.CheckConfig) CheckResult {
+	start := time.Now()
+	result := CheckResult{Name: check.Name, Type: check.Type}
+	if check.TableID != "" {
+		result.Table = fmt.Sprintf("%s.%s", check.DatasetID, check.TableID)
+	}
+
+	if result.Name == "" {
+		result.Name = fmt.Sprintf("%s %s %s", strings.ToLower(string(check.Type)), result.Table, strings.Join(check.Columns, ","))
+		result.Name = strings.TrimSpace(result.Name)
+	}
+
+	violations, err := q.checkViolations(check)
+	if err != nil {
+		result.Error = err.Error()
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	result.Query = checkQuery(violations, c.SampleSize)
+
+	type row struct {
+		Samples []string `bigquery:"samples"`
+		Total   int64    `bigquery:"total"`
+	}
+
+	err = func() error {
+		queryIterator, cancel, err := q.readQuery(result.Query, nil, c.Labels, c.Timeout)
+		defer cancel()
+		if err != nil {
+			return err
+		}
+
+		return iterateRows(queryIterator, func(r row) error {
+			result.Violations = r.Total
+			for _, s := range r.Samples {
+				var sample map[string]any
+				if err := json.Unmarshal([]byte(s), &sample); err != nil {
+					return err
+				}
+
+				result.Samples = append(result.Samples, sample)
+			}
+
+			return nil
+		})
+	}()
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	result.Passed = err == nil && result.Violations == 0
+	result.Duration = time.Since(start)
+	return result
+}
+
+// checkQuery return SQL query of a check returning total count of violating rows and up to sampleSize samples of them.
+// Total is counted separately, so it is not limited by sampleSize. Closing parenthesis is on its own line,
+// so a trailing line comment of the violations query does not hide it.
+func checkQuery(violations string, sampleSize int) string {
+	if sampleSize < 0 {
+		sampleSize = 0
+	}
+
+	return fmt.Sprintf("WITH violations AS (\n%s\n)\n"+
+		"SELECT (SELECT COUNT(*) FROM violations) AS total, "+
+		"ARRAY(SELECT TO_JSON_STRING(v) FROM violations v LIMIT %d) AS samples", violations, sampleSize)
+}
+
+// checkViolations return SQL query of a check returning its violating rows.
+func (q BigQuery) checkViolations(check Check) (string, error) {
+	if check.Type == CheckCustom {
+		if check.Query == "" {
+			return "", fmt.Errorf("%w: query is required", ErrInvalidCheck)
+		}
+
+		// Query is wrapped in a CTE, where a trailing semicolon is a syntax error
+		query := strings.TrimRightFunc(check.Query, func(r rune) bool { return r == ';' || unicode.IsSpace(r) })
+		query = strings.TrimSpace(query)
+		if query == "" {
+			return "", fmt.Errorf("%w: query is required", ErrInvalidCheck)
+		}
+
+		return query, nil
+	}
+
+	if check.DatasetID == "" || check.TableID == "" {
+		return "", fmt.Errorf("%w: table is required", ErrInvalidCheck)
+	}
+
+	// Checked rows, filtered when possible
+	source := q.tableRef(check.DatasetID, check.TableID)
+	if check.Where != "" {
+		source = fmt.Sprintf("(SELECT * FROM %s WHERE %s)", source, check.Where)
+	}
+
+	switch check.Type {
+	case CheckNotNull:
+		if len(check.Columns) == 0 {
+			return "", fmt.Errorf("%w: columns are required", ErrInvalidCheck)
+		}
+
+		var conditions []string
+		for _, column := range check.Columns {
+			conditions = append(conditions, fmt.Sprintf("`%s` IS NULL", column))
+		}
+
+		return fmt.Sprintf("SELECT * FROM %s WHERE %s", source, strings.Join(conditions, " OR ")), nil
+	case CheckUnique:
+		if len(check.Columns) == 0 {
+			return "", fmt.Errorf("%w: key columns are required", ErrInvalidCheck)
+		}
+
+		columns := quoteColumns(check.Columns)
+		return fmt.Sprintf("SELECT %s, COUNT(*) AS duplicates FROM %s GROUP BY %s HAVING COUNT(*) > 1",
+			columns, source, columns), nil
+	case CheckRowCount:
+		condition := fmt.Sprintf("row_count < %d", check.MinRows)
+		if check.MaxRows > 0 {
+			condition += fmt.Sprintf(" OR row_count > %d", check.MaxRows)
+		}
+
+		return fmt.Sprintf("SELECT row_count FROM (SELECT COUNT(*) AS row_count FROM %s) WHERE %s", source, condition), nil
+	case CheckFreshness:
+		if len(check.Columns) != 1 || check.MaxAge <= 0 {
+			return "", fmt.Errorf("%w: a column and max age are required", ErrInvalidCheck)
+		}
+
+		return fmt.Sprintf("SELECT latest, TIMESTAMP_DIFF(CURRENT_TIMESTAMP(), latest, SECOND) AS age_seconds "+
+			"FROM (SELECT CAST(MAX(`%s`) AS TIMESTAMP) AS latest FROM %s) "+
+			"WHERE latest IS NULL OR latest < TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL %d SECOND)",
+			check.Columns[0], source, int64(check.MaxAge.Seconds())), nil
+	case CheckReferential:
+		if len(check.Columns) == 0 || len(check.Columns) != len(check.RefColumns) || check.RefTableID == "" {
+			return "", fmt.Errorf("%w: referenced table and matching columns are required", ErrInvalidCheck)
+		}
+
+		refDatasetID := check.RefDatasetID
+		if refDatasetID == "" {
+			refDatasetID = check.DatasetID
+		}
+
+		var joins, notNulls []string
+		for i, column := range check.Columns {
+			joins = append(joins, fmt.Sprintf("T.`%s` = R.`%s`", column, check.RefColumns[i]))
+			notNulls = append(notNulls, fmt.Sprintf("T.`%s` IS NOT NULL", column))
+		}
+
+		return fmt.Sprintf("SELECT T.* FROM %s T LEFT JOIN (SELECT DISTINCT %s, TRUE AS __found FROM %s) R ON %s "+
+			"WHERE R.__found IS NULL AND %s",
+			source, quoteColumns(check.RefColumns), q.tableRef(refDatasetID, check.RefTableID),
+			strings.Join(joins, " AND "), strings.Join(notNulls, " AND ")), nil
+	default:
+		return "", fmt.Errorf("%w: unknown type %s", ErrInvalidCheck, check.Type)
+	}
+}
+
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = fmt.Sprintf("`%s`", column)
+	}
+
+	return strings.Join(quoted, ", ")
+}
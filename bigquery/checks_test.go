@@ -0,0 +1,166 @@
+package bigquery
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckViolations(t *testing.T) {
+	q := BigQuery{projectID: "p"}
+
+	tests := []struct {
+		name    string
+		check   Check
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "not null",
+			check: Check{Type: CheckNotNull, DatasetID: "d", TableID: "t", Columns: []string{"a", "b"}},
+			want:  "SELECT * FROM `p.d.t` WHERE `a` IS NULL OR `b` IS NULL",
+		},
+		{
+			name:  "not null with filter",
+			check: Check{Type: CheckNotNull, DatasetID: "d", TableID: "t", Columns: []string{"a"}, Where: "dt = CURRENT_DATE()"},
+			want:  "SELECT * FROM (SELECT * FROM `p.d.t` WHERE dt = CURRENT_DATE()) WHERE `a` IS NULL",
+		},
+		{
+			name:  "unique",
+			check: Check{Type: CheckUnique, DatasetID: "d", TableID: "t", Columns: []string{"a", "b"}},
+			want:  "SELECT `a`, `b`, COUNT(*) AS duplicates FROM `p.d.t` GROUP BY `a`, `b` HAVING COUNT(*) > 1",
+		},
+		{
+			name:  "row count with min rows",
+			check: Check{Type: CheckRowCount, DatasetID: "d", TableID: "t", MinRows: 10},
+			want:  "SELECT row_count FROM (SELECT COUNT(*) AS row_count FROM `p.d.t`) WHERE row_count < 10",
+		},
+		{
+			name:  "row count with range",
+			check: Check{Type: CheckRowCount, DatasetID: "d", TableID: "t", MinRows: 1, MaxRows: 100},
+			want:  "SELECT row_count FROM (SELECT COUNT(*) AS row_count FROM `p.d.t`) WHERE row_count < 1 OR row_count > 100",
+		},
+		{
+			name:  "freshness",
+			check: Check{Type: CheckFreshness, DatasetID: "d", TableID: "t", Columns: []string{"updated_at"}, MaxAge: time.Hour},
+			want: "SELECT latest, TIMESTAMP_DIFF(CURRENT_TIMESTAMP(), latest, SECOND) AS age_seconds " +
+				"FROM (SELECT CAST(MAX(`updated_at`) AS TIMESTAMP) AS latest FROM `p.d.t`) " +
+				"WHERE latest IS NULL OR latest < TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL 3600 SECOND)",
+		},
+		{
+			name: "referential in the same dataset",
+			check: Check{Type: CheckReferential, DatasetID: "d", TableID: "t", Columns: []string{"user_id"},
+				RefTableID: "users", RefColumns: []string{"id"}},
+			want: "SELECT T.* FROM `p.d.t` T LEFT JOIN (SELECT DISTINCT `id`, TRUE AS __found FROM `p.d.users`) R " +
+				"ON T.`user_id` = R.`id` WHERE R.__found IS NULL AND T.`user_id` IS NOT NULL",
+		},
+		{
+			name: "referential with composite key in other dataset",
+			check: Check{Type: CheckReferential, DatasetID: "d", TableID: "t", Columns: []string{"a", "b"},
+				RefDatasetID: "r", RefTableID: "u", RefColumns: []string{"x", "y"}},
+			want: "SELECT T.* FROM `p.d.t` T LEFT JOIN (SELECT DISTINCT `x`, `y`, TRUE AS __found FROM `p.r.u`) R " +
+				"ON T.`a` = R.`x` AND T.`b` = R.`y` WHERE R.__found IS NULL AND T.`a` IS NOT NULL AND T.`b` IS NOT NULL",
+		},
+		{
+			name:  "custom",
+			check: Check{Type: CheckCustom, Query: "SELECT 1"},
+			want:  "SELECT 1",
+		},
+		{
+			name:  "custom with trailing semicolon",
+			check: Check{Type: CheckCustom, Query: "\n  SELECT 1 -- ones\n; ;\n"},
+			want:  "SELECT 1 -- ones",
+		},
+		{
+			name:    "custom without query",
+			check:   Check{Type: CheckCustom},
+			wantErr: true,
+		},
+		{
+			name:    "custom with only semicolon",
+			check:   Check{Type: CheckCustom, Query: " ; "},
+			wantErr: true,
+		},
+		{
+			name:    "missing table",
+			check:   Check{Type: CheckNotNull, Columns: []string{"a"}},
+			wantErr: true,
+		},
+		{
+			name:    "not null without columns",
+			check:   Check{Type: CheckNotNull, DatasetID: "d", TableID: "t"},
+			wantErr: true,
+		},
+		{
+			name:    "unique without columns",
+			check:   Check{Type: CheckUnique, DatasetID: "d", TableID: "t"},
+			wantErr: true,
+		},
+		{
+			name:    "freshness without max age",
+			check:   Check{Type: CheckFreshness, DatasetID: "d", TableID: "t", Columns: []string{"updated_at"}},
+			wantErr: true,
+		},
+		{
+			name: "referential with mismatched columns",
+			check: Check{Type: CheckReferential, DatasetID: "d", TableID: "t", Columns: []string{"a", "b"},
+				RefTableID: "u", RefColumns: []string{"x"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			check:   Check{Type: "UNKNOWN", DatasetID: "d", TableID: "t"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := q.checkViolations(tt.check)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCheck) {
+					t.Fatalf("checkViolations() error = %v, want %v", err, ErrInvalidCheck)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("checkViolations() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("checkViolations() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampleSize int
+		want       string
+	}{
+		{
+			name:       "samples",
+			sampleSize: 10,
+			want: "WITH violations AS (\nSELECT 1 -- ones\n)\n" +
+				"SELECT (SELECT COUNT(*) FROM violations) AS total, ARRAY(SELECT TO_JSON_STRING(v) FROM violations v LIMIT 10) AS samples",
+		},
+		{
+			name:       "no samples",
+			sampleSize: 0,
+			want: "WITH violations AS (\nSELECT 1 -- ones\n)\n" +
+				"SELECT (SELECT COUNT(*) FROM violations) AS total, ARRAY(SELECT TO_JSON_STRING(v) FROM violations v LIMIT 0) AS samples",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkQuery("SELECT 1 -- ones", tt.sampleSize); got != tt.want {
+				t.Errorf("checkQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
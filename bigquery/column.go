@@ -1,13 +1,27 @@
 package bigquery
 
 import (
+	"cloud.google.com/go/bigquery"
 	"fmt"
 	"strings"
 )
 
 type Column struct {
-	ColumnName string `json:"columnName,omitempty" bigquery:"column_name"`
-	DataType   string `json:"dataType,omitempty"   bigquery:"data_type"`
+	ColumnName  string   `json:"columnName,omitempty"  bigquery:"column_name"`
+	DataType    string   `json:"dataType,omitempty"    bigquery:"data_type"`
+	Mode        string   `json:"mode,omitempty"        bigquery:"mode"`
+	Description string   `json:"description,omitempty" bigquery:"description"`
+	PolicyTags  []string `json:"policyTags,omitempty"  bigquery:"policy_tags"`
+
+	// Path is dotted path of the column, for example "address.city".
+	Path string `json:"path,omitempty" bigquery:"path"`
+
+	// InRepeated represent whether the column is nested inside a REPEATED RECORD,
+	// so it has many values per row even when its own mode is NULLABLE or REQUIRED.
+	InRepeated bool `json:"inRepeated,omitempty" bigquery:"in_repeated"`
+
+	// Columns contains child columns of a RECORD column.
+	Columns Columns `json:"columns,omitempty" bigquery:"-"`
 }
 
 type Columns []Column
@@ -26,3 +40,48 @@ func (c Columns) String() string {
 
 	return strings.Join(cols, ", ")
 }
+
+// Flatten return leaf columns, RECORD columns are replaced by their child columns recursively.
+// Use Path to identify a leaf column, a leaf inside a REPEATED RECORD keeps its own mode and has InRepeated set.
+func (c Columns) Flatten() Columns {
+	var leaves Columns
+	for _, col := range c {
+		if len(col.Columns) > 0 {
+			leaves = append(leaves, col.Columns.Flatten()...)
+			continue
+		}
+
+		leaves = append(leaves, col)
+	}
+
+	return leaves
+}
+
+// newColumns return columns metadata of a schema, including nested columns of RECORD columns.
+// Columns of a schema nested inside a REPEATED RECORD have InRepeated set.
+func newColumns(prefix string, schema bigquery.Schema, inRepeated bool) Columns {
+	var columns Columns
+	for _, field := range schema {
+		path := prefix + field.Name
+		column := Column{
+			ColumnName:  field.Name,
+			DataType:    string(field.Type),
+			Mode:        fieldMode(field),
+			Description: field.Description,
+			Path:        path,
+			InRepeated:  inRepeated,
+		}
+
+		if field.PolicyTags != nil {
+			column.PolicyTags = field.PolicyTags.Names
+		}
+
+		if field.Type == bigquery.RecordFieldType {
+			column.Columns = newColumns(path+".", field.Schema, inRepeated || field.Repeated)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns
+}
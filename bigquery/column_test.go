@@ -0,0 +1,94 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"reflect"
+	"testing"
+)
+
+func TestColumnsFlatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema bigquery.Schema
+		want   Columns
+	}{
+		{
+			name: "scalar columns",
+			schema: bigquery.Schema{
+				{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+			},
+			want: Columns{
+				{ColumnName: "id", DataType: "INTEGER", Mode: modeRequired, Path: "id"},
+				{ColumnName: "tags", DataType: "STRING", Mode: modeRepeated, Path: "tags"},
+			},
+		},
+		{
+			name: "nullable record",
+			schema: bigquery.Schema{
+				{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+					{Name: "city", Type: bigquery.StringFieldType},
+				}},
+			},
+			want: Columns{
+				{ColumnName: "city", DataType: "STRING", Mode: modeNullable, Path: "address.city"},
+			},
+		},
+		{
+			name: "repeated record",
+			schema: bigquery.Schema{
+				{Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+					{Name: "name", Type: bigquery.StringFieldType, Required: true},
+					{Name: "items", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "sku", Type: bigquery.StringFieldType},
+					}},
+				}},
+			},
+			want: Columns{
+				{ColumnName: "name", DataType: "STRING", Mode: modeRequired, Path: "events.name", InRepeated: true},
+				{ColumnName: "sku", DataType: "STRING", Mode: modeNullable, Path: "events.items.sku", InRepeated: true},
+			},
+		},
+		{
+			name: "repeated record inside nullable record",
+			schema: bigquery.Schema{
+				{Name: "order", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.StringFieldType},
+					{Name: "lines", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+						{Name: "qty", Type: bigquery.IntegerFieldType},
+					}},
+				}},
+			},
+			want: Columns{
+				{ColumnName: "id", DataType: "STRING", Mode: modeNullable, Path: "order.id"},
+				{ColumnName: "qty", DataType: "INTEGER", Mode: modeNullable, Path: "order.lines.qty", InRepeated: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newColumns("", tt.schema, false).Flatten()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Flatten() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewColumnsRepeatedRecord(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+			{Name: "sku", Type: bigquery.StringFieldType},
+		}},
+	}
+
+	columns := newColumns("", schema, false)
+	if len(columns) != 1 || columns[0].Mode != modeRepeated || columns[0].InRepeated {
+		t.Fatalf("newColumns() = %+v, want a REPEATED record that is not nested in a repeated record", columns)
+	}
+
+	if child := columns[0].Columns; len(child) != 1 || !child[0].InRepeated || child[0].Path != "events.sku" {
+		t.Errorf("newColumns() child columns = %+v, want events.sku inside a repeated record", child)
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+// BudgetConfig is a config for query cost guardrails of a BigQuery client.
+// When not initialized there will be no guardrails.
+type BudgetConfig struct {
+	// MaxBytesBilled max bytes billed per query (Optional). Have default value of 0 (have no limit).
+	// Can be overridden per query using RunQueryConfig.MaxBytesBilled.
+	MaxBytesBilled int64
+
+	// DailyBytesBilled max bytes billed by all queries of the client in one day (UTC) (Optional).
+	// Have default value of 0 (have no limit).
+	DailyBytesBilled int64
+
+	// DryRunFirst represent whether every query will be dry run to estimate its bytes before execution (Optional).
+	DryRunFirst bool
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery"
+	"time"
+)
+
+const (
+	BufferedInserterConfigMaxRows       = 500
+	BufferedInserterConfigMaxBytes      = 5 * 1024 * 1024
+	BufferedInserterConfigFlushInterval = time.Second
+	BufferedInserterConfigRetry         = RunQueryConfigRetry
+	BufferedInserterConfigDelay         = RunQueryConfigDelay
+	BufferedInserterConfigTimeout       = 30 * time.Second
+)
+
+// BufferedInserterConfig is a config for BufferedInserter.
+// When not initialized will be used default values.
+type BufferedInserterConfig struct {
+	// MaxRows max number of buffered rows before rows will be flushed (Optional). Have default value of 500.
+	MaxRows int
+
+	// MaxBytes max estimated size of buffered rows before rows will be flushed (Optional). Have default value of 5 MiB.
+	MaxBytes int
+
+	// FlushInterval max duration rows will be buffered before flushed (Optional). Have default value of 1 s.
+	FlushInterval time.Duration
+
+	// Retry number of retries of a flush failed with a retryable error (Optional). Have default value of 3.
+	Retry int
+
+	// Delay duration taken before the first retry, doubled on each next retry (Optional). Have default value of 500 ms.
+	Delay time.Duration
+
+	// Timeout max duration of one insert request (Optional). Have default value of 30 s.
+	Timeout time.Duration
+
+	// OnError called for every row that could not be inserted (Optional), for example to send it to a dead-letter sink.
+	// Err is either the row insertion error, or the last flush error when retries were exhausted.
+	OnError func(row bigquery.ValueSaver, err error)
+}
+
+// BufferedInserterConfigDefault is an instance of default BufferedInserterConfig.
+// You can use this config as reference for your own config.
+var BufferedInserterConfigDefault = BufferedInserterConfig{
+	MaxRows:       BufferedInserterConfigMaxRows,
+	MaxBytes:      BufferedInserterConfigMaxBytes,
+	FlushInterval: BufferedInserterConfigFlushInterval,
+	Retry:         BufferedInserterConfigRetry,
+	Delay:         BufferedInserterConfigDelay,
+	Timeout:       BufferedInserterConfigTimeout,
+	OnError:       nil,
+}
+
+// InitBufferedInserterConfig return an initialized BufferedInserterConfig with filled-in default values.
+func InitBufferedInserterConfig(config ...BufferedInserterConfig) BufferedInserterConfig {
+	if len(config) == 0 {
+		return BufferedInserterConfigDefault
+	}
+
+	c := config[0]
+	if c.MaxRows <= 0 {
+		c.MaxRows = BufferedInserterConfigMaxRows
+	}
+
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = BufferedInserterConfigMaxBytes
+	}
+
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = BufferedInserterConfigFlushInterval
+	}
+
+	if c.Retry < 0 {
+		c.Retry = BufferedInserterConfigRetry
+	}
+
+	if c.Delay < 0 {
+		c.Delay = BufferedInserterConfigDelay
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = BufferedInserterConfigTimeout
+	}
+
+	return c
+}
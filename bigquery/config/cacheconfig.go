@@ -0,0 +1,58 @@
+package config
+
+import (
+	"time"
+)
+
+const (
+	CacheConfigTTL            = 5 * time.Minute
+	CacheConfigMaxResultBytes = 8 << 20
+
+	CacheConfigInvalidationRefresh = 10 * time.Second
+)
+
+// CacheConfig is a config for query result cache.
+// When not initialized will be used default values.
+type CacheConfig struct {
+	// TTL duration before a cached result expires (Optional). Have default value of 5 minutes.
+	TTL time.Duration
+
+	// MaxResultBytes max size of an encoded result that will be cached (Optional). Have default value of 8 MiB.
+	// Larger results are returned without being cached.
+	MaxResultBytes int
+
+	// InvalidationRefresh duration before invalidation time of a table is read again from the backend (Optional). Have default value of 10 seconds.
+	// Invalidations by other clients sharing the backend could be unnoticed for this duration.
+	InvalidationRefresh time.Duration
+}
+
+// CacheConfigDefault is an instance of default CacheConfig.
+// You can use this config as reference for your own config.
+var CacheConfigDefault = CacheConfig{
+	TTL:            CacheConfigTTL,
+	MaxResultBytes: CacheConfigMaxResultBytes,
+
+	InvalidationRefresh: CacheConfigInvalidationRefresh,
+}
+
+// InitCacheConfig return an initialized CacheConfig with filled-in default values.
+func InitCacheConfig(config ...CacheConfig) CacheConfig {
+	if len(config) == 0 {
+		return CacheConfigDefault
+	}
+
+	c := config[0]
+	if c.TTL <= 0 {
+		c.TTL = CacheConfigTTL
+	}
+
+	if c.MaxResultBytes <= 0 {
+		c.MaxResultBytes = CacheConfigMaxResultBytes
+	}
+
+	if c.InvalidationRefresh <= 0 {
+		c.InvalidationRefresh = CacheConfigInvalidationRefresh
+	}
+
+	return c
+}
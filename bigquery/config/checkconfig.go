@@ -0,0 +1,57 @@
+package config
+
+import (
+	"time"
+)
+
+const (
+	CheckConfigConcurrency = 4
+	CheckConfigSampleSize  = 10
+	CheckConfigTimeout     = RunQueryConfigTimeout
+)
+
+// CheckConfig is a config for RunChecks function.
+// When not initialized will be used default values.
+type CheckConfig struct {
+	// Labels set labels that will be used when run query jobs of the checks (Optional).
+	Labels Labels
+
+	// Concurrency max number of checks run in parallel (Optional). Have default value of 4.
+	Concurrency int
+
+	// SampleSize max number of violating rows kept as samples of a failed check (Optional). Have default value of 10.
+	SampleSize int
+
+	// Timeout max duration before one check will be cancelled (Optional). Have default value of 0 (have no timeout).
+	Timeout time.Duration
+}
+
+// CheckConfigDefault is an instance of default CheckConfig.
+// You can use this config as reference for your own config.
+var CheckConfigDefault = CheckConfig{
+	Concurrency: CheckConfigConcurrency,
+	SampleSize:  CheckConfigSampleSize,
+	Timeout:     CheckConfigTimeout,
+}
+
+// InitCheckConfig return an initialized CheckConfig with filled-in default values.
+func InitCheckConfig(config ...CheckConfig) CheckConfig {
+	if len(config) == 0 {
+		return CheckConfigDefault
+	}
+
+	c := config[0]
+	if c.Concurrency <= 0 {
+		c.Concurrency = CheckConfigConcurrency
+	}
+
+	if c.SampleSize <= 0 {
+		c.SampleSize = CheckConfigSampleSize
+	}
+
+	if c.Timeout < 0 {
+		c.Timeout = CheckConfigTimeout
+	}
+
+	return c
+}
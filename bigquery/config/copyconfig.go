@@ -0,0 +1,86 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery"
+	"github.com/tiketdatarisal/gcp/shared"
+	"time"
+)
+
+const (
+	CopyConfigRetry            = RunQueryConfigRetry
+	CopyConfigWriteDisposition = bigquery.WriteEmpty
+	CopyConfigDelay            = RunQueryConfigDelay
+	CopyConfigTimeout          = RunQueryConfigTimeout
+)
+
+// CopyConfig is a config for CopyTable, SnapshotTable, CloneTable and RestoreFromSnapshot functions.
+// When not initialized will be used default values.
+type CopyConfig struct {
+	// Labels set labels that will be used when run a copy job (Optional).
+	Labels Labels
+
+	// WriteDisposition represent how data is written to existing destination table (Optional). Have default value of empty.
+	// Supported dispositions are bigquery.WriteAppend, bigquery.WriteTruncate and bigquery.WriteEmpty.
+	WriteDisposition bigquery.TableWriteDisposition
+
+	// Expiration duration after the copy before destination table will be deleted (Optional).
+	// Have default value of 0 (never expire).
+	Expiration time.Duration
+
+	// SnapshotTime represent point in time of source tables that will be copied (Optional).
+	// Have default value of zero time (current data). Must be within time travel window of the source tables.
+	SnapshotTime time.Time
+
+	// Retry number of retries (Optional). Have default value of 3.
+	Retry int
+
+	// Delay duration taken before copy job will be retried (Optional). Have default value of 500 ms.
+	Delay time.Duration
+
+	// RetryPolicy policy of retrying failed jobs, overriding Retry and Delay (Optional).
+	// When MaxAttempts is not set, client retry policy is used with Retry and Delay. Only retryable errors are retried.
+	RetryPolicy shared.RetryPolicy
+
+	// Timeout max duration before one copy job will be cancelled (Optional). Have default value of 0 (have no timeout).
+	Timeout time.Duration
+}
+
+// CopyConfigDefault is an instance of default CopyConfig.
+// You can use this config as reference for your own config.
+var CopyConfigDefault = CopyConfig{
+	Labels:           nil,
+	WriteDisposition: CopyConfigWriteDisposition,
+	Retry:            CopyConfigRetry,
+	Delay:            CopyConfigDelay,
+	Timeout:          CopyConfigTimeout,
+}
+
+// InitCopyConfig return an initialized CopyConfig with filled-in default values.
+func InitCopyConfig(config ...CopyConfig) CopyConfig {
+	if len(config) == 0 {
+		return CopyConfigDefault
+	}
+
+	c := config[0]
+	if c.WriteDisposition == "" {
+		c.WriteDisposition = CopyConfigWriteDisposition
+	}
+
+	if c.Expiration < 0 {
+		c.Expiration = 0
+	}
+
+	if c.Retry < 0 {
+		c.Retry = CopyConfigRetry
+	}
+
+	if c.Delay < 0 {
+		c.Delay = CopyConfigDelay
+	}
+
+	if c.Timeout < 0 {
+		c.Timeout = CopyConfigTimeout
+	}
+
+	return c
+}
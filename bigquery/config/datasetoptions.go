@@ -0,0 +1,33 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery"
+	"time"
+)
+
+// DatasetOptions is options used when creating or updating a dataset.
+// When updating, options with zero value are left unchanged.
+type DatasetOptions struct {
+	// Location represent geo location of the dataset, for example "US" or "asia-southeast2" (Optional).
+	// Only used when creating, location of an existing dataset could not be changed.
+	Location string
+
+	// DefaultTableExpiration default expiration of new tables (Optional). Have default value of 0 (never expire).
+	DefaultTableExpiration time.Duration
+
+	// DefaultPartitionExpiration default expiration of partitions of new partitioned tables (Optional).
+	// Have default value of 0 (never expire).
+	DefaultPartitionExpiration time.Duration
+
+	// Labels set labels of the dataset (Optional). When updating, labels are added or replaced.
+	Labels Labels
+
+	// DeleteLabels represent label keys that will be deleted (Optional). Only used when updating.
+	DeleteLabels []string
+
+	// Description represent description of the dataset (Optional).
+	Description string
+
+	// Access represent access entries of the dataset (Optional). When updating, access entries are replaced.
+	Access []*bigquery.AccessEntry
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery"
+	"time"
+)
+
+const (
+	JobTypeQuery   = "QUERY"
+	JobTypeLoad    = "LOAD"
+	JobTypeExtract = "EXTRACT"
+	JobTypeCopy    = "COPY"
+
+	ListJobsConfigTimeout = 30 * time.Second
+)
+
+// ListJobsConfig is a config for ListJobs function, jobs are filtered by all the set filters.
+// When not initialized will be used default values.
+type ListJobsConfig struct {
+	// State represent state of listed jobs (Optional). Have default value of bigquery.StateUnspecified (all states).
+	// Supported states are bigquery.Pending, bigquery.Running and bigquery.Done.
+	State bigquery.State
+
+	// MinCreationTime represent jobs created at or after this time are listed (Optional).
+	MinCreationTime time.Time
+
+	// MaxCreationTime represent jobs created at or before this time are listed (Optional).
+	MaxCreationTime time.Time
+
+	// AllUsers represent whether jobs of all users are listed, instead of only jobs of the caller (Optional).
+	AllUsers bool
+
+	// UserEmail represent email of the user who ran listed jobs (Optional). Jobs of all users are searched when set.
+	UserEmail string
+
+	// JobType represent type of listed jobs (Optional).
+	// Supported types are JobTypeQuery, JobTypeLoad, JobTypeExtract and JobTypeCopy.
+	JobType string
+
+	// Labels represent labels of listed jobs (Optional). Empty value matches any value of the label key.
+	Labels Labels
+
+	// MaxResults max number of listed jobs (Optional). Have default value of 0 (no limit).
+	MaxResults int
+
+	// Timeout max duration of listing (Optional). Have default value of 30 s.
+	Timeout time.Duration
+}
+
+// ListJobsConfigDefault is an instance of default ListJobsConfig.
+// You can use this config as reference for your own config.
+var ListJobsConfigDefault = ListJobsConfig{
+	Timeout: ListJobsConfigTimeout,
+}
+
+// InitListJobsConfig return an initialized ListJobsConfig with filled-in default values.
+func InitListJobsConfig(config ...ListJobsConfig) ListJobsConfig {
+	if len(config) == 0 {
+		return ListJobsConfigDefault
+	}
+
+	c := config[0]
+	if c.UserEmail != "" {
+		c.AllUsers = true
+	}
+
+	if c.MaxResults < 0 {
+		c.MaxResults = 0
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = ListJobsConfigTimeout
+	}
+
+	return c
+}
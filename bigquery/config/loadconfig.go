@@ -0,0 +1,113 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery"
+	"github.com/tiketdatarisal/gcp/shared"
+	"time"
+)
+
+const (
+	LoadConfigRetry            = RunQueryConfigRetry
+	LoadConfigFormat           = bigquery.CSV
+	LoadConfigWriteDisposition = bigquery.WriteAppend
+	LoadConfigDelimiter        = RunQueryConfigDelimiterComma
+	LoadConfigDelay            = RunQueryConfigDelay
+	LoadConfigTimeout          = RunQueryConfigTimeout
+)
+
+// LoadConfig is a config for LoadFromXXX functions.
+// When not initialized will be used default values.
+type LoadConfig struct {
+	// Labels set labels that will be used when run a load job (Optional).
+	Labels Labels
+
+	// Format represent file format of loaded data (Optional). Have default value of CSV.
+	// Supported formats are bigquery.CSV, bigquery.JSON, bigquery.Parquet, bigquery.Avro and bigquery.ORC.
+	Format bigquery.DataFormat
+
+	// Schema represent schema of loaded data (Optional). Ignored when AutoDetect is set.
+	// Not required for self-describing formats (Parquet, Avro and ORC) or when the table already exists.
+	Schema bigquery.Schema
+
+	// AutoDetect represent whether schema will be inferred from loaded data (Optional).
+	AutoDetect bool
+
+	// WriteDisposition represent how loaded data is written to existing table (Optional). Have default value of append.
+	// Supported dispositions are bigquery.WriteAppend, bigquery.WriteTruncate and bigquery.WriteEmpty.
+	WriteDisposition bigquery.TableWriteDisposition
+
+	// SkipLeadingRows number of rows at the top of a CSV file that will be skipped (Optional).
+	SkipLeadingRows int64
+
+	// MaxBadRecords max number of bad records ignored before the load job fails (Optional).
+	MaxBadRecords int64
+
+	// Delimiter represent delimiter used when loading data from CSV (Optional). Have default value of (,) comma.
+	Delimiter string
+
+	// Partition represent partition decorator of destination table (Optional).
+	// For example: "20230101" will load data into partition of 2023-01-01 of a daily partitioned table.
+	Partition string
+
+	// UseAvroLogicalTypes represent whether Avro logical types will be interpreted as BigQuery types (Optional).
+	UseAvroLogicalTypes bool
+
+	// Retry number of retries (Optional). Have default value of 3.
+	Retry int
+
+	// Delay duration taken before load job will be retried (Optional). Have default value of 500 ms.
+	Delay time.Duration
+
+	// RetryPolicy policy of retrying failed jobs, overriding Retry and Delay (Optional).
+	// When MaxAttempts is not set, client retry policy is used with Retry and Delay. Only retryable errors are retried.
+	RetryPolicy shared.RetryPolicy
+
+	// Timeout max duration before one load job will be cancelled (Optional). Have default value of 0 (have no timeout).
+	Timeout time.Duration
+}
+
+// LoadConfigDefault is an instance of default LoadConfig.
+// You can use this config as reference for your own config.
+var LoadConfigDefault = LoadConfig{
+	Labels:           nil,
+	Format:           LoadConfigFormat,
+	WriteDisposition: LoadConfigWriteDisposition,
+	Delimiter:        LoadConfigDelimiter,
+	Retry:            LoadConfigRetry,
+	Delay:            LoadConfigDelay,
+	Timeout:          LoadConfigTimeout,
+}
+
+// InitLoadConfig return an initialized LoadConfig with filled-in default values.
+func InitLoadConfig(config ...LoadConfig) LoadConfig {
+	if len(config) == 0 {
+		return LoadConfigDefault
+	}
+
+	c := config[0]
+	if c.Format == "" {
+		c.Format = LoadConfigFormat
+	}
+
+	if c.WriteDisposition == "" {
+		c.WriteDisposition = LoadConfigWriteDisposition
+	}
+
+	if c.Delimiter == "" {
+		c.Delimiter = LoadConfigDelimiter
+	}
+
+	if c.Retry < 0 {
+		c.Retry = LoadConfigRetry
+	}
+
+	if c.Delay < 0 {
+		c.Delay = LoadConfigDelay
+	}
+
+	if c.Timeout < 0 {
+		c.Timeout = LoadConfigTimeout
+	}
+
+	return c
+}
@@ -0,0 +1,42 @@
+package config
+
+import "time"
+
+const (
+	MaterializedViewOptionsDisableRefresh  = false
+	MaterializedViewOptionsRefreshInterval = 30 * time.Minute
+)
+
+// MaterializedViewOptions is options used when creating a materialized view.
+// When not initialized will be used default values, the materialized view is refreshed automatically.
+type MaterializedViewOptions struct {
+	// DisableRefresh represent whether automatic refresh of the materialized view is disabled (Optional).
+	DisableRefresh bool
+
+	// RefreshInterval max frequency the materialized view will be refreshed (Optional). Have default value of 30 minutes.
+	RefreshInterval time.Duration
+
+	// TableOptions represent partitioning, clustering, expiration, description and labels of the materialized view (Optional).
+	TableOptions TableOptions
+}
+
+// MaterializedViewOptionsDefault is an instance of default MaterializedViewOptions.
+// You can use this config as reference for your own config.
+var MaterializedViewOptionsDefault = MaterializedViewOptions{
+	DisableRefresh:  MaterializedViewOptionsDisableRefresh,
+	RefreshInterval: MaterializedViewOptionsRefreshInterval,
+}
+
+// InitMaterializedViewOptions return an initialized MaterializedViewOptions with filled-in default values.
+func InitMaterializedViewOptions(options ...MaterializedViewOptions) MaterializedViewOptions {
+	if len(options) == 0 {
+		return MaterializedViewOptionsDefault
+	}
+
+	o := options[0]
+	if !o.DisableRefresh && o.RefreshInterval <= 0 {
+		o.RefreshInterval = MaterializedViewOptionsRefreshInterval
+	}
+
+	return o
+}
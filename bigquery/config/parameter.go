@@ -0,0 +1,8 @@
+package config
+
+import "cloud.google.com/go/bigquery"
+
+// Parameters is a list of query parameters.
+// Use named parameters (with Name) for @name placeholders, or positional parameters (without Name) for ? placeholders.
+// Value may be a scalar, a slice (ARRAY), a struct (STRUCT), a time.Time (TIMESTAMP), a civil type or a bigquery.QueryParameterValue.
+type Parameters = []bigquery.QueryParameter
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery"
+	"time"
+)
+
+// Arrow specifies Apache Arrow format of rows read through BigQuery Storage Read API.
+const Arrow bigquery.DataFormat = "ARROW"
+
+const (
+	ReadConfigFormat     = bigquery.Avro
+	ReadConfigMaxStreams = 4
+	ReadConfigBufferSize = 1000
+	ReadConfigRetry      = RunQueryConfigRetry
+	ReadConfigDelay      = RunQueryConfigDelay
+	ReadConfigTimeout    = RunQueryConfigTimeout
+)
+
+// ReadConfig is a config for ReadTableFunc and ReadQueryFunc functions, reading through BigQuery Storage Read API.
+// When not initialized will be used default values.
+type ReadConfig struct {
+	// Labels set labels that will be used when run a query job (Optional).
+	Labels Labels
+
+	// Parameters set query parameters that will be used when run a query job (Optional).
+	Parameters Parameters
+
+	// MaxBytesBilled max bytes billed for the query job (Optional). Have default value of 0 (use client budget).
+	MaxBytesBilled int64
+
+	// Columns represent names of columns that will be read (Optional). Have default value of nil (all columns).
+	Columns []string
+
+	// RowRestriction represent SQL filter of rows that will be read, for example "age > 17" (Optional).
+	RowRestriction string
+
+	// SnapshotTime represent point in time of table data that will be read (Optional).
+	// Have default value of zero time (current data).
+	SnapshotTime time.Time
+
+	// Format represent format of rows transferred from BigQuery, either bigquery.Avro or Arrow (Optional).
+	// Have default value of bigquery.Avro. Both formats are decoded into the same row values.
+	Format bigquery.DataFormat
+
+	// MaxStreams max number of streams read in parallel (Optional). Have default value of 4.
+	MaxStreams int
+
+	// BufferSize max number of decoded rows held in memory before delivered to func (Optional).
+	// Have default value of 1000.
+	BufferSize int
+
+	// Retry number of retries of a failed stream, resumed from its last offset (Optional). Have default value of 3.
+	Retry int
+
+	// Delay duration taken before a failed stream will be retried (Optional). Have default value of 500 ms.
+	Delay time.Duration
+
+	// Timeout max duration before reading will be cancelled (Optional). Have default value of 0 (have no timeout).
+	Timeout time.Duration
+}
+
+// ReadConfigDefault is an instance of default ReadConfig.
+// You can use this config as reference for your own config.
+var ReadConfigDefault = ReadConfig{
+	Format:     ReadConfigFormat,
+	MaxStreams: ReadConfigMaxStreams,
+	BufferSize: ReadConfigBufferSize,
+	Retry:      ReadConfigRetry,
+	Delay:      ReadConfigDelay,
+	Timeout:    ReadConfigTimeout,
+}
+
+// InitReadConfig return an initialized ReadConfig with filled-in default values.
+func InitReadConfig(config ...ReadConfig) ReadConfig {
+	if len(config) == 0 {
+		return ReadConfigDefault
+	}
+
+	c := config[0]
+	if c.MaxBytesBilled < 0 {
+		c.MaxBytesBilled = 0
+	}
+
+	if c.Format == "" {
+		c.Format = ReadConfigFormat
+	}
+
+	if c.MaxStreams <= 0 {
+		c.MaxStreams = ReadConfigMaxStreams
+	}
+
+	if c.BufferSize <= 0 {
+		c.BufferSize = ReadConfigBufferSize
+	}
+
+	if c.Retry < 0 {
+		c.Retry = ReadConfigRetry
+	}
+
+	if c.Delay < 0 {
+		c.Delay = ReadConfigDelay
+	}
+
+	if c.Timeout < 0 {
+		c.Timeout = ReadConfigTimeout
+	}
+
+	return c
+}
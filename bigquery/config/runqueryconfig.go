@@ -1,6 +1,10 @@
 package config
 
-import "time"
+import (
+	"cloud.google.com/go/bigquery"
+	"github.com/tiketdatarisal/gcp/shared"
+	"time"
+)
 
 const (
 	RunQueryConfigRetry              = 3
@@ -10,43 +14,96 @@ const (
 	RunQueryConfigDisableHeader      = false
 	RunQueryConfigDelay              = 500 * time.Millisecond
 	RunQueryConfigTimeout            = 0
+	RunQueryConfigMaxBytesBilled     = 0
+	RunQueryConfigDryRunFirst        = false
+	RunQueryConfigFormat             = bigquery.CSV
 )
 
+// Zstd specifies ZSTD compression for Parquet files.
+const Zstd bigquery.Compression = "ZSTD"
+
+// ExportCompressions is a list of compressions supported by each export format.
+// The first compression is used when RunQueryConfig.Compressed is set without Codec.
+var ExportCompressions = map[bigquery.DataFormat][]bigquery.Compression{
+	bigquery.CSV:     {bigquery.Gzip},
+	bigquery.JSON:    {bigquery.Gzip},
+	bigquery.Parquet: {bigquery.Snappy, bigquery.Gzip, Zstd},
+	bigquery.Avro:    {bigquery.Snappy, bigquery.Deflate},
+}
+
 // RunQueryConfig is a config for RunQueryXXX functions.
 // When not initialized will be used default values.
 type RunQueryConfig struct {
 	// Labels set labels that will be used when run a query job (Optional).
 	Labels Labels
 
+	// Parameters set query parameters that will be used when run a query job (Optional).
+	Parameters Parameters
+
 	// Retry number of retries (Optional). Have default value of 3.
 	Retry int
 
 	// Compressed represent whether the query result stored will be compressed or not (Optional).
+	// Compressed with GZIP for CSV and JSON, and with SNAPPY for Parquet and Avro.
 	Compressed bool
 
+	// Format represent file format of exported data (Optional). Have default value of CSV.
+	// Supported formats are bigquery.CSV, bigquery.JSON, bigquery.Parquet and bigquery.Avro.
+	Format bigquery.DataFormat
+
+	// Codec represent compression of exported data, overriding Compressed (Optional).
+	// See ExportCompressions for codecs supported by each format.
+	Codec bigquery.Compression
+
+	// UseAvroLogicalTypes represent whether Avro logical types will be used for exported data (Optional).
+	UseAvroLogicalTypes bool
+
 	// Delimiter represent delimiter used when exporting data to CSV (Optional). Have default value of (,) comma.
 	Delimiter string
 
 	// DisableHeader represent whether exported data will omit the header (Optional).
 	DisableHeader bool
 
+	// PageTokenKey represent secret key used to sign and verify page tokens of RunQueryPage.
+	// Required by RunQueryPage unless the client key is set using SetPageTokenKey.
+	// Use the same key on all replicas, so the next page could be fetched by any of them.
+	PageTokenKey []byte
+
 	// Delay duration taken before query job will be retried (Optional). Have default value of 500 ms.
 	Delay time.Duration
 
+	// RetryPolicy policy of retrying failed jobs, overriding Retry and Delay (Optional).
+	// When MaxAttempts is not set, client retry policy is used with Retry and Delay. Only retryable errors are retried.
+	RetryPolicy shared.RetryPolicy
+
 	// Timeout max duration before one query job will be cancelled (Optional). Have default value of 0 (have no timeout).
 	Timeout time.Duration
+
+	// MaxBytesBilled max bytes billed for the query job (Optional). Have default value of 0 (use client budget).
+	MaxBytesBilled int64
+
+	// DryRunFirst represent whether the query will be dry run to estimate its bytes before execution (Optional).
+	DryRunFirst bool
+
+	// UseSession represent whether the script will be run inside the client session, created when needed (Optional).
+	// Temporary tables and variables of the session are kept across scripts. Only used by RunScript.
+	UseSession bool
 }
 
 // RunQueryConfigDefault is an instance of default RunQueryConfig.
 // You can use this config as reference for your own config.
 var RunQueryConfigDefault = RunQueryConfig{
-	Labels:        nil,
-	Retry:         RunQueryConfigRetry,
-	Compressed:    RunQueryConfigCompressed,
-	Delimiter:     RunQueryConfigDelimiterComma,
-	DisableHeader: RunQueryConfigDisableHeader,
-	Delay:         RunQueryConfigDelay,
-	Timeout:       RunQueryConfigTimeout,
+	Labels:         nil,
+	Parameters:     nil,
+	Retry:          RunQueryConfigRetry,
+	Compressed:     RunQueryConfigCompressed,
+	Format:         RunQueryConfigFormat,
+	Delimiter:      RunQueryConfigDelimiterComma,
+	DisableHeader:  RunQueryConfigDisableHeader,
+	Delay:          RunQueryConfigDelay,
+	Timeout:        RunQueryConfigTimeout,
+	MaxBytesBilled: RunQueryConfigMaxBytesBilled,
+	DryRunFirst:    RunQueryConfigDryRunFirst,
 }
 
 // InitRunQueryConfig return an initialized RunQueryConfig with filled-in default values.
@@ -60,6 +117,10 @@ func InitRunQueryConfig(config ...RunQueryConfig) RunQueryConfig {
 		c.Retry = RunQueryConfigRetry
 	}
 
+	if c.Format == "" {
+		c.Format = RunQueryConfigFormat
+	}
+
 	if c.Delimiter == "" {
 		c.Delimiter = RunQueryConfigDelimiterComma
 	}
@@ -72,5 +133,9 @@ func InitRunQueryConfig(config ...RunQueryConfig) RunQueryConfig {
 		c.Timeout = RunQueryConfigTimeout
 	}
 
+	if c.MaxBytesBilled < 0 {
+		c.MaxBytesBilled = RunQueryConfigMaxBytesBilled
+	}
+
 	return c
 }
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"time"
+)
+
+const (
+	StreamWriterConfigUseOffsets = false
+	StreamWriterConfigTimeout    = 30 * time.Second
+)
+
+// StreamWriterConfigType is the default type of write stream.
+var StreamWriterConfigType = managedwriter.DefaultStream
+
+// StreamWriterConfig is a config for StreamWriter.
+// When not initialized will be used default values.
+type StreamWriterConfig struct {
+	// Type represent type of write stream (Optional). Have default value of managedwriter.DefaultStream.
+	// Supported types are managedwriter.DefaultStream, managedwriter.CommittedStream and managedwriter.PendingStream.
+	Type managedwriter.StreamType
+
+	// UseOffsets represent whether rows will be appended at explicit offsets for exactly-once delivery (Optional).
+	// Not supported by managedwriter.DefaultStream.
+	UseOffsets bool
+
+	// Timeout max duration of one append (Optional). Have default value of 30 s.
+	Timeout time.Duration
+}
+
+// StreamWriterConfigDefault is an instance of default StreamWriterConfig.
+// You can use this config as reference for your own config.
+var StreamWriterConfigDefault = StreamWriterConfig{
+	Type:       StreamWriterConfigType,
+	UseOffsets: StreamWriterConfigUseOffsets,
+	Timeout:    StreamWriterConfigTimeout,
+}
+
+// InitStreamWriterConfig return an initialized StreamWriterConfig with filled-in default values.
+func InitStreamWriterConfig(config ...StreamWriterConfig) StreamWriterConfig {
+	if len(config) == 0 {
+		return StreamWriterConfigDefault
+	}
+
+	c := config[0]
+	if c.Type == "" {
+		c.Type = StreamWriterConfigType
+	}
+
+	if c.Type == managedwriter.DefaultStream {
+		c.UseOffsets = false
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = StreamWriterConfigTimeout
+	}
+
+	return c
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"cloud.google.com/go/bigquery"
+	"time"
+)
+
+// TableOptions is options used when creating a table.
+// When not initialized the table will be created without partitioning, clustering and expiration.
+type TableOptions struct {
+	// TimePartitioning represent time-based partitioning of the table (Optional).
+	TimePartitioning *bigquery.TimePartitioning
+
+	// RangePartitioning represent integer-range partitioning of the table (Optional).
+	// Ignored when TimePartitioning is set.
+	RangePartitioning *bigquery.RangePartitioning
+
+	// RequirePartitionFilter represent whether queries of the table must filter on the partitioning column (Optional).
+	RequirePartitionFilter bool
+
+	// ClusteringFields represent columns used to cluster the table, in order of priority (Optional).
+	ClusteringFields []string
+
+	// Expiration duration after creation before the table will be deleted (Optional). Have default value of 0 (never expire).
+	Expiration time.Duration
+
+	// Description represent description of the table (Optional).
+	Description string
+
+	// Labels set labels of the table (Optional).
+	Labels Labels
+}
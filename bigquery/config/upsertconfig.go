@@ -0,0 +1,83 @@
+package config
+
+import (
+	"time"
+)
+
+const (
+	UpsertConfigStagingExpiration = time.Hour
+	UpsertConfigRetry             = RunQueryConfigRetry
+	UpsertConfigDelay             = RunQueryConfigDelay
+	UpsertConfigTimeout           = RunQueryConfigTimeout
+)
+
+// UpsertConfig is a config for UpsertRows function.
+// When not initialized will be used default values, matched rows are updated and new rows are inserted.
+type UpsertConfig struct {
+	// Labels set labels that will be used when run the MERGE query job (Optional).
+	Labels Labels
+
+	// UpdateColumns represent columns updated when a row is matched (Optional).
+	// Have default value of nil (all columns except key columns).
+	UpdateColumns []string
+
+	// DisableUpdate represent whether matched rows are left unchanged (Optional).
+	DisableUpdate bool
+
+	// DisableInsert represent whether new rows are not inserted (Optional).
+	DisableInsert bool
+
+	// DeleteWhenNotMatched represent whether rows of the table that are not in the upserted rows are deleted (Optional).
+	DeleteWhenNotMatched bool
+
+	// StagingDatasetID represent dataset of the staging table (Optional). Have default value of the table dataset.
+	StagingDatasetID string
+
+	// StagingExpiration duration before the staging table will be deleted, when it could not be cleaned up (Optional).
+	// Have default value of 1 hour.
+	StagingExpiration time.Duration
+
+	// Retry number of retries of inserting rows into the staging table, and of the merge job (Optional). Have default value of 3.
+	Retry int
+
+	// Delay duration taken before the first retry (Optional). Have default value of 500 ms.
+	Delay time.Duration
+
+	// Timeout max duration before the MERGE query job will be cancelled (Optional). Have default value of 0 (have no timeout).
+	Timeout time.Duration
+}
+
+// UpsertConfigDefault is an instance of default UpsertConfig.
+// You can use this config as reference for your own config.
+var UpsertConfigDefault = UpsertConfig{
+	StagingExpiration: UpsertConfigStagingExpiration,
+	Retry:             UpsertConfigRetry,
+	Delay:             UpsertConfigDelay,
+	Timeout:           UpsertConfigTimeout,
+}
+
+// InitUpsertConfig return an initialized UpsertConfig with filled-in default values.
+func InitUpsertConfig(config ...UpsertConfig) UpsertConfig {
+	if len(config) == 0 {
+		return UpsertConfigDefault
+	}
+
+	c := config[0]
+	if c.StagingExpiration <= 0 {
+		c.StagingExpiration = UpsertConfigStagingExpiration
+	}
+
+	if c.Retry < 0 {
+		c.Retry = UpsertConfigRetry
+	}
+
+	if c.Delay < 0 {
+		c.Delay = UpsertConfigDelay
+	}
+
+	if c.Timeout < 0 {
+		c.Timeout = UpsertConfigTimeout
+	}
+
+	return c
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"time"
+)
+
+// ViewOptions is options used when creating a logical view.
+// Partitioning and clustering are not available, BigQuery rejects them for a logical view.
+type ViewOptions struct {
+	// Expiration duration after creation before the view will be deleted (Optional). Have default value of 0 (never expire).
+	Expiration time.Duration
+
+	// Description represent description of the view (Optional).
+	Description string
+
+	// Labels set labels of the view (Optional).
+	Labels Labels
+}
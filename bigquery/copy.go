@@ -0,0 +1,138 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"strings"
+	"time"
+)
+
+// CopyResult is statistics of a finished copy job.
+type CopyResult struct {
+	JobID              string
+	Location           string
+	CopiedRows         int64
+	CopiedLogicalBytes int64
+}
+
+// CopyTable copy one or more tables into a destination table.
+// Source table is formatted as "dataset.table" or "project.dataset.table".
+func (q BigQuery) CopyTable(srcTableIDs []string, dstDatasetID, dstTableID string, cfg ...config.CopyConfig) (*CopyResult, error) {
+	if len(srcTableIDs) == 0 {
+		return nil, nil
+	}
+
+	var srcTables []*bigquery.Table
+	for _, id := range srcTableIDs {
+		table, err := q.tableFromID(id)
+		if err != nil {
+			return nil, fmt.Errorf(errorWrapper, ErrCopyTableFailed, err)
+		}
+
+		srcTables = append(srcTables, table)
+	}
+
+	dstTable := q.client.Dataset(dstDatasetID).Table(dstTableID)
+	return q.copyTable(srcTables, dstTable, bigquery.CopyOperation, config.InitCopyConfig(cfg...))
+}
+
+// SnapshotTable create a read-only snapshot of a table, optionally at a point in time from SnapshotTime.
+// Use Expiration to delete the snapshot automatically.
+// When the snapshot is created but its expiration could not be set, the result is returned with ErrSetExpirationFailed.
+func (q BigQuery) SnapshotTable(srcDatasetID, srcTableID, dstDatasetID, dstTableID string, cfg ...config.CopyConfig) (*CopyResult, error) {
+	srcTable := q.client.Dataset(srcDatasetID).Table(srcTableID)
+	dstTable := q.client.Dataset(dstDatasetID).Table(dstTableID)
+	return q.copyTable([]*bigquery.Table{srcTable}, dstTable, bigquery.SnapshotOperation, config.InitCopyConfig(cfg...))
+}
+
+// CloneTable create a writable clone of a table, optionally at a point in time from SnapshotTime.
+// Only changes made after cloning are billed as storage of the clone.
+func (q BigQuery) CloneTable(srcDatasetID, srcTableID, dstDatasetID, dstTableID string, cfg ...config.CopyConfig) (*CopyResult, error) {
+	srcTable := q.client.Dataset(srcDatasetID).Table(srcTableID)
+	dstTable := q.client.Dataset(dstDatasetID).Table(dstTableID)
+	return q.copyTable([]*bigquery.Table{srcTable}, dstTable, bigquery.CloneOperation, config.InitCopyConfig(cfg...))
+}
+
+// RestoreFromSnapshot restore a table from a snapshot.
+// Use bigquery.WriteTruncate as WriteDisposition to overwrite an existing table.
+func (q BigQuery) RestoreFromSnapshot(snapshotDatasetID, snapshotTableID, dstDatasetID, dstTableID string, cfg ...config.CopyConfig) (*CopyResult, error) {
+	c := config.InitCopyConfig(cfg...)
+	c.SnapshotTime = time.Time{}
+
+	srcTable := q.client.Dataset(snapshotDatasetID).Table(snapshotTableID)
+	dstTable := q.client.Dataset(dstDatasetID).Table(dstTableID)
+	return q.copyTable([]*bigquery.Table{srcTable}, dstTable, bigquery.RestoreOperation, c)
+}
+
+// copyTable run a copy job with operation type, and set expiration of destination table when possible.
+// Return the result together with ErrSetExpirationFailed when only setting the expiration has failed.
+func (q BigQuery) copyTable(srcTables []*bigquery.Table, dstTable *bigquery.Table, operation bigquery.TableCopyOperationType, c config.CopyConfig) (*CopyResult, error) {
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	// Use table decorator to copy source tables at a point in time
+	if !c.SnapshotTime.IsZero() {
+		for i, t := range srcTables {
+			srcTables[i] = q.client.DatasetInProject(t.ProjectID, t.DatasetID).
+				Table(fmt.Sprintf("%s@%d", t.TableID, c.SnapshotTime.UnixMilli()))
+		}
+	}
+
+	copier := dstTable.CopierFrom(srcTables...)
+	copier.OperationType = operation
+	copier.WriteDisposition = c.WriteDisposition
+	if c.Labels != nil {
+		copier.Labels = c.Labels
+	}
+
+	job, err := q.runJob(ctx, &copier.JobIDConfig, q.retryPolicy(c.RetryPolicy, c.Retry, c.Delay), func() (*bigquery.Job, error) {
+		return copier.Run(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrCopyTableFailed, err)
+	}
+
+	// Copy has succeeded even when expiration could not be set, so the result is returned with the error
+	result := q.newCopyResult(ctx, job)
+	if c.Expiration > 0 {
+		update := bigquery.TableMetadataToUpdate{ExpirationTime: time.Now().Add(c.Expiration)}
+		if _, err = dstTable.Update(ctx, update, ""); err != nil {
+			return result, fmt.Errorf(errorWrapper, ErrSetExpirationFailed, err)
+		}
+	}
+
+	return result, nil
+}
+
+// newCopyResult return statistics of a finished copy job.
+func (q BigQuery) newCopyResult(ctx context.Context, job *bigquery.Job) *CopyResult {
+	result := &CopyResult{
+		JobID:    job.ID(),
+		Location: job.Location(),
+	}
+
+	// Copy statistics are only available from BigQuery API
+	res, err := q.service.Jobs.Get(job.ProjectID(), job.ID()).Location(job.Location()).Context(ctx).Do()
+	if err == nil && res.Statistics != nil && res.Statistics.Copy != nil {
+		result.CopiedRows = res.Statistics.Copy.CopiedRows
+		result.CopiedLogicalBytes = res.Statistics.Copy.CopiedLogicalBytes
+	}
+
+	return result
+}
+
+// tableFromID return a table from its ID, formatted as "dataset.table" or "project.dataset.table".
+func (q BigQuery) tableFromID(id string) (*bigquery.Table, error) {
+	parts := strings.Split(strings.Trim(id, "`"), ".")
+	switch len(parts) {
+	case 2:
+		return q.client.Dataset(parts[0]).Table(parts[1]), nil
+	case 3:
+		return q.client.DatasetInProject(parts[0], parts[1]).Table(parts[2]), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrInvalidTableID, id)
+}
@@ -0,0 +1,98 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+)
+
+// CreateDataset create a new dataset with options.
+func (q BigQuery) CreateDataset(datasetID string, opts ...config.DatasetOptions) error {
+	meta := &bigquery.DatasetMetadata{}
+	if len(opts) > 0 {
+		o := opts[0]
+		meta.Location = o.Location
+		meta.DefaultTableExpiration = o.DefaultTableExpiration
+		meta.DefaultPartitionExpiration = o.DefaultPartitionExpiration
+		meta.Labels = o.Labels
+		meta.Description = o.Description
+		meta.Access = o.Access
+	}
+
+	if err := q.client.Dataset(datasetID).Create(q.ctx, meta); err != nil {
+		return fmt.Errorf(errorWrapper, ErrCreateDatasetFailed, err)
+	}
+
+	return nil
+}
+
+// DeleteDataset delete an existing dataset.
+// Dataset must be empty, unless deleteContents is set to delete its tables as well.
+func (q BigQuery) DeleteDataset(datasetID string, deleteContents ...bool) error {
+	dataset := q.client.Dataset(datasetID)
+
+	var err error
+	if len(deleteContents) > 0 && deleteContents[0] {
+		err = dataset.DeleteWithContents(q.ctx)
+	} else {
+		err = dataset.Delete(q.ctx)
+	}
+
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrDeleteDatasetFailed, err)
+	}
+
+	return nil
+}
+
+// GetDatasetMetadata return metadata of an existing dataset.
+func (q BigQuery) GetDatasetMetadata(datasetID string) (*bigquery.DatasetMetadata, error) {
+	meta, err := q.client.Dataset(datasetID).Metadata(q.ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrGetDatasetMetadataFailed, err)
+	}
+
+	return meta, nil
+}
+
+// UpdateDataset update an existing dataset with options, and return its updated metadata.
+// Options with zero value are left unchanged. The update fails when the dataset has been modified concurrently.
+func (q BigQuery) UpdateDataset(datasetID string, opts config.DatasetOptions) (*bigquery.DatasetMetadata, error) {
+	dataset := q.client.Dataset(datasetID)
+	meta, err := dataset.Metadata(q.ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrUpdateDatasetFailed, err)
+	}
+
+	var update bigquery.DatasetMetadataToUpdate
+	if opts.DefaultTableExpiration > 0 {
+		update.DefaultTableExpiration = opts.DefaultTableExpiration
+	}
+
+	if opts.DefaultPartitionExpiration > 0 {
+		update.DefaultPartitionExpiration = opts.DefaultPartitionExpiration
+	}
+
+	if opts.Description != "" {
+		update.Description = opts.Description
+	}
+
+	if opts.Access != nil {
+		update.Access = opts.Access
+	}
+
+	for key, value := range opts.Labels {
+		update.SetLabel(key, value)
+	}
+
+	for _, key := range opts.DeleteLabels {
+		update.DeleteLabel(key)
+	}
+
+	meta, err = dataset.Update(q.ctx, update, meta.ETag)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrUpdateDatasetFailed, err)
+	}
+
+	return meta, nil
+}
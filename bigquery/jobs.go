@@ -0,0 +1,222 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"sort"
+	"strings"
+	"time"
+
+	bq "google.golang.org/api/bigquery/v2"
+)
+
+// tebibyte is number of bytes in a TiB, the unit of on-demand query pricing.
+const tebibyte = 1 << 40
+
+// JobInfo is a past or running job with its statistics.
+type JobInfo struct {
+	ProjectID string
+	JobID     string
+	Location  string
+	Type      string
+	State     string
+	UserEmail string
+	Labels    map[string]string
+
+	CreationTime time.Time
+	StartTime    time.Time
+	EndTime      time.Time
+	Duration     time.Duration
+
+	BytesProcessed int64
+	BytesBilled    int64
+	SlotMillis     int64
+	CacheHit       bool
+	StatementType  string
+
+	// Error is error of a failed job, nil when the job succeeded or has not finished.
+	Error *bigquery.Error
+}
+
+// LabelCost is cost of jobs sharing a label value.
+type LabelCost struct {
+	Value       string
+	Jobs        int
+	BytesBilled int64
+	SlotMillis  int64
+	Cost        float64
+}
+
+// ListJobs return jobs of the client project, newest first, filtered by config.
+// State and creation time are filtered by BigQuery, other filters are applied to the listed jobs.
+func (q BigQuery) ListJobs(cfg ...config.ListJobsConfig) ([]JobInfo, error) {
+	// Get config from parameter
+	c := config.InitListJobsConfig(cfg...)
+
+	ctx, cancel := context.WithTimeout(q.ctx, c.Timeout)
+	defer cancel()
+
+	call := q.service.Jobs.List(q.projectID).Projection("full").AllUsers(c.AllUsers)
+	if state := jobStateFilter(c.State); state != "" {
+		call = call.StateFilter(state)
+	}
+
+	if !c.MinCreationTime.IsZero() {
+		call = call.MinCreationTime(uint64(c.MinCreationTime.UnixMilli()))
+	}
+
+	if !c.MaxCreationTime.IsZero() {
+		call = call.MaxCreationTime(uint64(c.MaxCreationTime.UnixMilli()))
+	}
+
+	var jobs []JobInfo
+	t := ""
+	for {
+		res, err := call.PageToken(t).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf(errorWrapper, ErrListJobsFailed, err)
+		}
+
+		for _, j := range res.Jobs {
+			job := newJobInfo(j)
+			if !matchJob(job, c) {
+				continue
+			}
+
+			jobs = append(jobs, job)
+			if c.MaxResults > 0 && len(jobs) >= c.MaxResults {
+				return jobs, nil
+			}
+		}
+
+		t = res.NextPageToken
+		if t == "" {
+			break
+		}
+	}
+
+	return jobs, nil
+}
+
+// CostByLabel sum cost of jobs per value of a label key, sorted by the highest cost.
+// Cost is bytes billed multiplied by on-demand price per TiB, jobs without the label are summed under empty value.
+func CostByLabel(jobs []JobInfo, labelKey string, pricePerTiB float64) []LabelCost {
+	costs := map[string]*LabelCost{}
+	for _, job := range jobs {
+		value := job.Labels[labelKey]
+		cost, exists := costs[value]
+		if !exists {
+			cost = &LabelCost{Value: value}
+			costs[value] = cost
+		}
+
+		cost.Jobs++
+		cost.BytesBilled += job.BytesBilled
+		cost.SlotMillis += job.SlotMillis
+	}
+
+	result := make([]LabelCost, 0, len(costs))
+	for _, cost := range costs {
+		cost.Cost = float64(cost.BytesBilled) / tebibyte * pricePerTiB
+		result = append(result, *cost)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BytesBilled != result[j].BytesBilled {
+			return result[i].BytesBilled > result[j].BytesBilled
+		}
+
+		return result[i].Value < result[j].Value
+	})
+
+	return result
+}
+
+func jobStateFilter(state bigquery.State) string {
+	switch state {
+	case bigquery.Pending:
+		return "pending"
+	case bigquery.Running:
+		return "running"
+	case bigquery.Done:
+		return "done"
+	default:
+		return ""
+	}
+}
+
+// matchJob return true when a job matches user, job type and labels filters.
+func matchJob(job JobInfo, c config.ListJobsConfig) bool {
+	if c.UserEmail != "" && !strings.EqualFold(job.UserEmail, c.UserEmail) {
+		return false
+	}
+
+	if c.JobType != "" && !strings.EqualFold(job.Type, c.JobType) {
+		return false
+	}
+
+	for key, value := range c.Labels {
+		v, exists := job.Labels[key]
+		if !exists || (value != "" && v != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newJobInfo return job info from a listed job.
+func newJobInfo(j *bq.JobListJobs) JobInfo {
+	job := JobInfo{
+		State:     j.State,
+		UserEmail: j.UserEmail,
+	}
+
+	if j.JobReference != nil {
+		job.ProjectID = j.JobReference.ProjectId
+		job.JobID = j.JobReference.JobId
+		job.Location = j.JobReference.Location
+	}
+
+	if j.Configuration != nil {
+		job.Type = j.Configuration.JobType
+		job.Labels = j.Configuration.Labels
+	}
+
+	if j.ErrorResult != nil {
+		job.Error = &bigquery.Error{
+			Location: j.ErrorResult.Location,
+			Message:  j.ErrorResult.Message,
+			Reason:   j.ErrorResult.Reason,
+		}
+	}
+
+	if s := j.Statistics; s != nil {
+		job.CreationTime = unixMilli(s.CreationTime)
+		job.StartTime = unixMilli(s.StartTime)
+		job.EndTime = unixMilli(s.EndTime)
+		if s.StartTime > 0 && s.EndTime > 0 {
+			job.Duration = job.EndTime.Sub(job.StartTime)
+		}
+
+		job.BytesProcessed = s.TotalBytesProcessed
+		job.SlotMillis = s.TotalSlotMs
+		if s.Query != nil {
+			job.BytesBilled = s.Query.TotalBytesBilled
+			job.CacheHit = s.Query.CacheHit
+			job.StatementType = s.Query.StatementType
+		}
+	}
+
+	return job
+}
+
+func unixMilli(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(ms)
+}
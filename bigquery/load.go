@@ -0,0 +1,182 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"io"
+)
+
+// LoadResult is statistics of a finished load job.
+type LoadResult struct {
+	JobID          string
+	Location       string
+	RowsLoaded     int64
+	BadRecords     int64
+	InputFiles     int64
+	InputFileBytes int64
+	OutputBytes    int64
+
+	// Errors contains errors of rows that could not be loaded, when allowed by MaxBadRecords.
+	Errors []*bigquery.Error
+}
+
+// LoadFromGCS load data from file(s) in GCS into a table.
+// Use wildcard (*) when you want to load from multiple files.
+// For example: gcsURIs = ["gs://bucket/sample-*.csv"] will load "sample-000000000000.csv",
+// "sample-000000000001.csv", etc.
+// When the load job failed, the returned result (when not nil) contains errors of rows that could not be loaded.
+func (q BigQuery) LoadFromGCS(datasetID, tableID string, gcsURIs []string, cfg ...config.LoadConfig) (*LoadResult, error) {
+	if datasetID == "" || tableID == "" || len(gcsURIs) == 0 {
+		return nil, nil
+	}
+
+	// Get config from parameter
+	c := config.InitLoadConfig(cfg...)
+
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	// Prepare to load, initialize table loader
+	gcsRef := bigquery.NewGCSReference(gcsURIs...)
+	applyLoadFileConfig(&gcsRef.FileConfig, c)
+
+	loader := q.newLoader(datasetID, tableID, gcsRef, c)
+	job, err := q.runJob(ctx, &loader.JobIDConfig, q.retryPolicy(c.RetryPolicy, c.Retry, c.Delay), func() (*bigquery.Job, error) {
+		return loader.Run(ctx)
+	})
+
+	return q.loadResult(ctx, job, err)
+}
+
+// LoadFromReader load data from a reader into a table using media upload, without staging the data in GCS.
+// Supported formats are the same as LoadFromGCS. The load job is only retried when the reader is an io.Seeker,
+// it is read again from the offset it had when LoadFromReader was called.
+// When the load job failed, the returned result (when not nil) contains errors of rows that could not be loaded.
+func (q BigQuery) LoadFromReader(datasetID, tableID string, r io.Reader, format bigquery.DataFormat, cfg ...config.LoadConfig) (*LoadResult, error) {
+	if datasetID == "" || tableID == "" || r == nil {
+		return nil, nil
+	}
+
+	// Get config from parameter
+	c := config.InitLoadConfig(cfg...)
+	if format != "" {
+		c.Format = format
+	}
+
+	// Reader could only be read again from its starting offset when it is a seeker,
+	// a pipe is a seeker as well but its offset could not be read
+	var start int64
+	seeker, seekable := r.(io.Seeker)
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	policy := q.retryPolicy(c.RetryPolicy, c.Retry, c.Delay)
+	if !seekable {
+		policy.MaxAttempts = 1
+	}
+
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	// Prepare to load, initialize table loader
+	readerSource := bigquery.NewReaderSource(r)
+	applyLoadFileConfig(&readerSource.FileConfig, c)
+
+	loader := q.newLoader(datasetID, tableID, readerSource, c)
+	job, err := q.runJob(ctx, &loader.JobIDConfig, policy, func() (*bigquery.Job, error) {
+		if seekable {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		return loader.Run(ctx)
+	})
+
+	return q.loadResult(ctx, job, err)
+}
+
+// loadResult return statistics of the last load job attempt together with its error.
+func (q BigQuery) loadResult(ctx context.Context, job *bigquery.Job, err error) (*LoadResult, error) {
+	var result *LoadResult
+	if job != nil {
+		result = q.newLoadResult(ctx, job)
+	}
+
+	if err != nil {
+		return result, fmt.Errorf(errorWrapper, ErrLoadFailed, err)
+	}
+
+	return result, nil
+}
+
+// applyLoadFileConfig apply format and schema options from config into file config.
+func applyLoadFileConfig(fc *bigquery.FileConfig, c config.LoadConfig) {
+	fc.SourceFormat = c.Format
+	fc.MaxBadRecords = c.MaxBadRecords
+	if c.AutoDetect {
+		fc.AutoDetect = true
+	} else if c.Schema != nil {
+		fc.Schema = c.Schema
+	}
+
+	if c.Format == bigquery.CSV {
+		fc.FieldDelimiter = c.Delimiter
+		fc.SkipLeadingRows = c.SkipLeadingRows
+	}
+}
+
+// newLoader return a loader into a table, or into a table partition when possible.
+func (q BigQuery) newLoader(datasetID, tableID string, src bigquery.LoadSource, c config.LoadConfig) *bigquery.Loader {
+	if c.Partition != "" {
+		tableID = fmt.Sprintf("%s$%s", tableID, c.Partition)
+	}
+
+	loader := q.client.Dataset(datasetID).Table(tableID).LoaderFrom(src)
+	loader.WriteDisposition = c.WriteDisposition
+	loader.UseAvroLogicalTypes = c.UseAvroLogicalTypes
+	if c.Labels != nil {
+		loader.Labels = c.Labels
+	}
+
+	return loader
+}
+
+// newLoadResult return statistics of a finished load job.
+func (q BigQuery) newLoadResult(ctx context.Context, job *bigquery.Job) *LoadResult {
+	result := &LoadResult{
+		JobID:    job.ID(),
+		Location: job.Location(),
+	}
+
+	status := job.LastStatus()
+	if status == nil {
+		return result
+	}
+
+	result.Errors = status.Errors
+	if status.Statistics != nil {
+		if loadStats, ok := status.Statistics.Details.(*bigquery.LoadStatistics); ok {
+			result.RowsLoaded = loadStats.OutputRows
+			result.InputFiles = loadStats.InputFiles
+			result.InputFileBytes = loadStats.InputFileBytes
+			result.OutputBytes = loadStats.OutputBytes
+		}
+	}
+
+	// Number of bad records is only available from BigQuery API
+	res, err := q.service.Jobs.Get(job.ProjectID(), job.ID()).Location(job.Location()).Context(ctx).Do()
+	if err == nil && res.Statistics != nil && res.Statistics.Load != nil {
+		result.BadRecords = res.Statistics.Load.BadRecords
+	}
+
+	return result
+}
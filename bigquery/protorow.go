@@ -0,0 +1,401 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	numericScale    = 9
+	bigNumericScale = 38
+)
+
+var civilEpoch = civil.Date{Year: 1970, Month: time.January, Day: 1}
+
+// rowToValues return values of a row keyed by column name.
+// Row could be a bigquery.ValueSaver, a map keyed by column name, or a struct (or its pointer) with `bigquery` tags.
+func rowToValues(row any, schema bigquery.Schema) (map[string]bigquery.Value, error) {
+	switch r := row.(type) {
+	case bigquery.ValueSaver:
+		values, _, err := r.Save()
+		return values, err
+	case map[string]bigquery.Value:
+		return r, nil
+	}
+
+	v := reflect.ValueOf(row)
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		values := map[string]bigquery.Value{}
+		for _, key := range v.MapKeys() {
+			values[key.String()] = v.MapIndex(key).Interface()
+		}
+
+		return values, nil
+	}
+
+	saver := &bigquery.StructSaver{Struct: row, Schema: schema}
+	values, _, err := saver.Save()
+	return values, err
+}
+
+// valuesToMessage return a protobuf message of values, with fields numbered in the order of schema fields.
+func valuesToMessage(values map[string]bigquery.Value, schema bigquery.Schema, md protoreflect.MessageDescriptor) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(md)
+	for i, field := range schema {
+		fd := md.Fields().ByNumber(protoreflect.FieldNumber(i + 1))
+		if fd == nil {
+			return nil, fmt.Errorf("no descriptor for column %s", field.Name)
+		}
+
+		value := lookupValue(values, field.Name)
+		if isNullValue(value) {
+			if field.Required {
+				return nil, fmt.Errorf("column %s is required", field.Name)
+			}
+
+			continue
+		}
+
+		if field.Repeated {
+			items := reflect.ValueOf(value)
+			if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+				return nil, fmt.Errorf("column %s is repeated, got %T", field.Name, value)
+			}
+
+			list := msg.Mutable(fd).List()
+			for j := 0; j < items.Len(); j++ {
+				item, err := toProtoValue(fd, field, items.Index(j).Interface())
+				if err != nil {
+					return nil, err
+				}
+
+				list.Append(item)
+			}
+
+			continue
+		}
+
+		item, err := toProtoValue(fd, field, value)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Set(fd, item)
+	}
+
+	return msg, nil
+}
+
+// lookupValue return value of a column, column name is matched case-insensitively when not found.
+func lookupValue(values map[string]bigquery.Value, name string) bigquery.Value {
+	if value, exists := values[name]; exists {
+		return value
+	}
+
+	for key, value := range values {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return nil
+}
+
+// isNullValue return true when value is nil, a nil pointer, or an invalid bigquery.NullXXX.
+func isNullValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bigquery.NullInt64:
+		return !v.Valid
+	case bigquery.NullString:
+		return !v.Valid
+	case bigquery.NullGeography:
+		return !v.Valid
+	case bigquery.NullJSON:
+		return !v.Valid
+	case bigquery.NullFloat64:
+		return !v.Valid
+	case bigquery.NullBool:
+		return !v.Valid
+	case bigquery.NullTimestamp:
+		return !v.Valid
+	case bigquery.NullDate:
+		return !v.Valid
+	case bigquery.NullTime:
+		return !v.Valid
+	case bigquery.NullDateTime:
+		return !v.Valid
+	}
+
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// unwrapNullValue return underlying value of a valid bigquery.NullXXX, or the value itself.
+func unwrapNullValue(value any) any {
+	switch v := value.(type) {
+	case bigquery.NullInt64:
+		return v.Int64
+	case bigquery.NullString:
+		return v.StringVal
+	case bigquery.NullGeography:
+		return v.GeographyVal
+	case bigquery.NullJSON:
+		return v.JSONVal
+	case bigquery.NullFloat64:
+		return v.Float64
+	case bigquery.NullBool:
+		return v.Bool
+	case bigquery.NullTimestamp:
+		return v.Timestamp
+	case bigquery.NullDate:
+		return v.Date
+	case bigquery.NullTime:
+		return v.Time
+	case bigquery.NullDateTime:
+		return v.DateTime
+	}
+
+	return value
+}
+
+// toProtoValue convert a single (non-repeated) value into protobuf value using encodings of Storage Write API.
+func toProtoValue(fd protoreflect.FieldDescriptor, field *bigquery.FieldSchema, value any) (protoreflect.Value, error) {
+	value = unwrapNullValue(value)
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if _, isRat := value.(*big.Rat); !isRat {
+			value = rv.Elem().Interface()
+		}
+	}
+
+	var err error
+	var result protoreflect.Value
+	switch field.Type {
+	case bigquery.StringFieldType, bigquery.GeographyFieldType, bigquery.JSONFieldType:
+		result = protoreflect.ValueOfString(fmt.Sprint(value))
+	case bigquery.BytesFieldType:
+		switch v := value.(type) {
+		case []byte:
+			result = protoreflect.ValueOfBytes(v)
+		case string:
+			result = protoreflect.ValueOfBytes([]byte(v))
+		default:
+			err = fmt.Errorf("unsupported value %T", value)
+		}
+	case bigquery.IntegerFieldType:
+		var n int64
+		n, err = toInt64(value)
+		result = protoreflect.ValueOfInt64(n)
+	case bigquery.FloatFieldType:
+		var f float64
+		f, err = toFloat64(value)
+		result = protoreflect.ValueOfFloat64(f)
+	case bigquery.BooleanFieldType:
+		switch v := value.(type) {
+		case bool:
+			result = protoreflect.ValueOfBool(v)
+		case string:
+			var b bool
+			b, err = strconv.ParseBool(v)
+			result = protoreflect.ValueOfBool(b)
+		default:
+			err = fmt.Errorf("unsupported value %T", value)
+		}
+	case bigquery.TimestampFieldType:
+		var t time.Time
+		t, err = toTime(value)
+		result = protoreflect.ValueOfInt64(t.UnixMicro())
+	case bigquery.DateFieldType:
+		var d civil.Date
+		d, err = toCivilDate(value)
+		result = protoreflect.ValueOfInt32(int32(d.DaysSince(civilEpoch)))
+	case bigquery.TimeFieldType:
+		var t civil.Time
+		t, err = toCivilTime(value)
+		result = protoreflect.ValueOfInt64(encodeCivilTime(t))
+	case bigquery.DateTimeFieldType:
+		var dt civil.DateTime
+		dt, err = toCivilDateTime(value)
+		result = protoreflect.ValueOfInt64(encodeCivilDateTime(dt))
+	case bigquery.NumericFieldType, bigquery.BigNumericFieldType:
+		scale := numericScale
+		if field.Type == bigquery.BigNumericFieldType {
+			scale = bigNumericScale
+		}
+
+		var r *big.Rat
+		r, err = toRat(value)
+		if err == nil {
+			result = protoreflect.ValueOfBytes(encodeNumeric(r, scale))
+		}
+	case bigquery.RecordFieldType:
+		var values map[string]bigquery.Value
+		values, err = rowToValues(value, field.Schema)
+		if err == nil {
+			var nested *dynamicpb.Message
+			nested, err = valuesToMessage(values, field.Schema, fd.Message())
+			if err == nil {
+				result = protoreflect.ValueOfMessage(nested)
+			}
+		}
+	default:
+		err = fmt.Errorf("unsupported column type %s", field.Type)
+	}
+
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("column %s: %w", field.Name, err)
+	}
+
+	return result, nil
+}
+
+func toInt64(value any) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.String:
+		return strconv.ParseInt(v.String(), 10, 64)
+	}
+
+	return 0, fmt.Errorf("unsupported value %T", value)
+}
+
+func toFloat64(value any) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.String:
+		return strconv.ParseFloat(v.String(), 64)
+	}
+
+	return 0, fmt.Errorf("unsupported value %T", value)
+}
+
+func toTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported value %T", value)
+}
+
+func toCivilDate(value any) (civil.Date, error) {
+	switch v := value.(type) {
+	case civil.Date:
+		return v, nil
+	case time.Time:
+		return civil.DateOf(v), nil
+	case string:
+		return civil.ParseDate(v)
+	}
+
+	return civil.Date{}, fmt.Errorf("unsupported value %T", value)
+}
+
+func toCivilTime(value any) (civil.Time, error) {
+	switch v := value.(type) {
+	case civil.Time:
+		return v, nil
+	case time.Time:
+		return civil.TimeOf(v), nil
+	case string:
+		return civil.ParseTime(v)
+	}
+
+	return civil.Time{}, fmt.Errorf("unsupported value %T", value)
+}
+
+func toCivilDateTime(value any) (civil.DateTime, error) {
+	switch v := value.(type) {
+	case civil.DateTime:
+		return v, nil
+	case time.Time:
+		return civil.DateTimeOf(v), nil
+	case string:
+		// BigQuery use a space as separator between date and time
+		return civil.ParseDateTime(strings.Replace(v, " ", "T", 1))
+	}
+
+	return civil.DateTime{}, fmt.Errorf("unsupported value %T", value)
+}
+
+func toRat(value any) (*big.Rat, error) {
+	switch v := value.(type) {
+	case *big.Rat:
+		return v, nil
+	case big.Rat:
+		return &v, nil
+	case string:
+		r, ok := new(big.Rat).SetString(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid numeric %q", v)
+		}
+
+		return r, nil
+	case float32, float64:
+		f, _ := toFloat64(v)
+		return new(big.Rat).SetFloat64(f), nil
+	}
+
+	n, err := toInt64(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Rat).SetInt64(n), nil
+}
+
+// encodeCivilTime return TIME encoded as packed 64-bit integer, as expected by Storage Write API.
+func encodeCivilTime(t civil.Time) int64 {
+	seconds := int64(t.Hour)<<12 | int64(t.Minute)<<6 | int64(t.Second)
+	return seconds<<20 | int64(t.Nanosecond/1000)
+}
+
+// encodeCivilDateTime return DATETIME encoded as packed 64-bit integer, as expected by Storage Write API.
+func encodeCivilDateTime(dt civil.DateTime) int64 {
+	seconds := int64(dt.Date.Year)<<26 | int64(dt.Date.Month)<<22 | int64(dt.Date.Day)<<17 |
+		int64(dt.Time.Hour)<<12 | int64(dt.Time.Minute)<<6 | int64(dt.Time.Second)
+	return seconds<<20 | int64(dt.Time.Nanosecond/1000)
+}
+
+// encodeNumeric return NUMERIC or BIGNUMERIC encoded as little-endian two's complement of the scaled value,
+// as expected by Storage Write API.
+func encodeNumeric(r *big.Rat, scale int) []byte {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	n := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+
+	// Two's complement of negative number, using one more byte than its magnitude
+	size := len(n.Bytes()) + 1
+	if n.Sign() < 0 {
+		n.Add(n, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+
+	be := n.FillBytes(make([]byte, size))
+	le := make([]byte, size)
+	for i, b := range be {
+		le[size-1-i] = b
+	}
+
+	return le
+}
@@ -0,0 +1,66 @@
+package bigquery
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// decodeNumeric return the scaled value of little-endian two's complement bytes.
+func decodeNumeric(le []byte, scale int) *big.Rat {
+	be := make([]byte, len(le))
+	for i, b := range le {
+		be[len(le)-1-i] = b
+	}
+
+	n := new(big.Int).SetBytes(be)
+	if len(be) > 0 && be[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(be)*8)))
+	}
+
+	return new(big.Rat).SetFrac(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+}
+
+func TestEncodeNumeric(t *testing.T) {
+	rat := func(s string) *big.Rat {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			t.Fatalf("invalid rat %s", s)
+		}
+
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		value *big.Rat
+		scale int
+		want  []byte
+		round string
+	}{
+		{name: "zero", value: rat("0"), scale: 9, want: []byte{0x00}, round: "0"},
+		{name: "one", value: rat("1"), scale: 9, want: []byte{0x00, 0xca, 0x9a, 0x3b, 0x00}, round: "1"},
+		{name: "minus one", value: rat("-1"), scale: 9, want: []byte{0x00, 0x36, 0x65, 0xc4, 0xff}, round: "-1"},
+		{name: "fraction", value: rat("0.5"), scale: 9, want: []byte{0x00, 0x65, 0xcd, 0x1d, 0x00}, round: "0.5"},
+		{name: "truncated to scale", value: rat("1/3"), scale: 9, want: []byte{0x55, 0x43, 0xde, 0x13, 0x00}, round: "0.333333333"},
+		{name: "negative truncated toward zero", value: rat("-1/3"), scale: 9, want: []byte{0xab, 0xbc, 0x21, 0xec, 0xff}, round: "-0.333333333"},
+		{name: "smallest negative byte", value: rat("-0.000000128"), scale: 9, want: []byte{0x80, 0xff}, round: "-0.000000128"},
+		{name: "max numeric", value: rat("99999999999999999999999999999.999999999"), scale: 9, round: "99999999999999999999999999999.999999999"},
+		{name: "min numeric", value: rat("-99999999999999999999999999999.999999999"), scale: 9, round: "-99999999999999999999999999999.999999999"},
+		{name: "bignumeric", value: rat("-578960446186580977117854925043439539266.34992332820282019728792003956564819967"), scale: 38,
+			round: "-578960446186580977117854925043439539266.34992332820282019728792003956564819967"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeNumeric(tt.value, tt.scale)
+			if tt.want != nil && !bytes.Equal(got, tt.want) {
+				t.Errorf("encodeNumeric() = % x, want % x", got, tt.want)
+			}
+
+			if decoded := decodeNumeric(got, tt.scale); decoded.Cmp(rat(tt.round)) != 0 {
+				t.Errorf("decoded encodeNumeric() = %s, want %s", decoded.FloatString(tt.scale), tt.round)
+			}
+		})
+	}
+}
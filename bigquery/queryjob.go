@@ -0,0 +1,173 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"sync"
+)
+
+// QueryJob is a handle of a query job running asynchronously.
+// Persist ID and Location to reattach the job later using AttachQueryJob.
+type QueryJob struct {
+	ID       string
+	Location string
+
+	ctx    context.Context
+	job    *bigquery.Job
+	record func(status *bigquery.JobStatus)
+}
+
+// SubmitQuery submit a query job within budget and return its handle without waiting for the job to finish.
+// Timeout from config is used as the job timeout, the job will be cancelled by BigQuery when exceeded.
+// Bytes billed by the job are added to the client running total once Wait or ReadFunc has returned.
+// Submission is retried using retry policy from config with the same job ID, so the job is never submitted twice.
+func (q BigQuery) SubmitQuery(query string, cfg ...config.RunQueryConfig) (*QueryJob, error) {
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	// Get config from parameter
+	c := config.InitRunQueryConfig(cfg...)
+
+	// Initialize task with parameters and labels when possible
+	task := q.newQuery(query, c.Parameters, c.Labels)
+	if c.Timeout > 0 {
+		task.JobTimeout = c.Timeout
+	}
+
+	if err := q.checkBudget(q.ctx, task, c); err != nil {
+		return nil, err
+	}
+
+	job, err := q.submitJob(q.ctx, &task.JobIDConfig, q.retryPolicy(c.RetryPolicy, c.Retry, c.Delay), func() (*bigquery.Job, error) {
+		return task.Run(q.ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrSubmitQueryFailed, err)
+	}
+
+	return q.newQueryJob(job), nil
+}
+
+// AttachQueryJob return handle of an existing query job from its job ID and location.
+func (q BigQuery) AttachQueryJob(jobID, location string) (*QueryJob, error) {
+	job, err := q.client.JobFromIDLocation(q.ctx, jobID, location)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrAttachQueryJobFailed, err)
+	}
+
+	jobConfig, err := job.Config()
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrAttachQueryJobFailed, err)
+	}
+
+	if _, ok := jobConfig.(*bigquery.QueryConfig); !ok {
+		return nil, fmt.Errorf(errorWrapper, ErrAttachQueryJobFailed, ErrNotQueryJob)
+	}
+
+	return q.newQueryJob(job), nil
+}
+
+func (q BigQuery) newQueryJob(job *bigquery.Job) *QueryJob {
+	var once sync.Once
+	return &QueryJob{
+		ID:       job.ID(),
+		Location: job.Location(),
+		ctx:      q.ctx,
+		job:      job,
+		record: func(status *bigquery.JobStatus) {
+			once.Do(func() { q.recordBytesBilled(status) })
+		},
+	}
+}
+
+// Status return the latest status of the query job.
+func (j QueryJob) Status() (*bigquery.JobStatus, error) {
+	status, err := j.job.Status(j.ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrGetQueryJobStatusFailed, err)
+	}
+
+	return status, nil
+}
+
+// Statistics return the latest statistics of the query job.
+func (j QueryJob) Statistics() (*bigquery.JobStatistics, error) {
+	status, err := j.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	return status.Statistics, nil
+}
+
+// Done return true when the query job has finished, either succeeded or failed.
+func (j QueryJob) Done() (bool, error) {
+	status, err := j.Status()
+	if err != nil {
+		return false, err
+	}
+
+	return status.Done(), nil
+}
+
+// Cancel request the query job to be cancelled.
+// Cancellation is best effort, use Wait to know whether the job has stopped.
+func (j QueryJob) Cancel() error {
+	if err := j.job.Cancel(j.ctx); err != nil {
+		return fmt.Errorf(errorWrapper, ErrCancelQueryJobFailed, err)
+	}
+
+	return nil
+}
+
+// Wait block until the query job has finished or the context is done.
+// Return an error when the query job failed.
+func (j QueryJob) Wait(ctx context.Context) error {
+	status, err := j.job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrWaitQueryJobFailed, err)
+	}
+
+	j.record(status)
+	if err := status.Err(); err != nil {
+		return fmt.Errorf(errorWrapper, ErrWaitQueryJobFailed, err)
+	}
+
+	return nil
+}
+
+// ReadFunc wait for the query job to finish and process its result in func.
+func (j QueryJob) ReadFunc(f func(row map[string]bigquery.Value) error) error {
+	return ReadQueryJobFuncInto(&j, f)
+}
+
+// ReadQueryJobFuncInto wait for the query job to finish and process its result decoded into T in func.
+func ReadQueryJobFuncInto[T any](j *QueryJob, f func(row T) error) error {
+	if f == nil {
+		return nil
+	}
+
+	status, err := j.job.Wait(j.ctx)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	j.record(status)
+	if err = status.Err(); err != nil {
+		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	queryIterator, err := j.job.Read(j.ctx)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	if err = iterateRows(queryIterator, f); err != nil {
+		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	return nil
+}
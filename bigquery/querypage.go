@@ -0,0 +1,227 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"google.golang.org/api/iterator"
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+// QueryPage is a page of query result.
+type QueryPage[T any] struct {
+	Rows []T
+
+	// NextPageToken is an opaque token to fetch the next page, empty on the last page.
+	NextPageToken string
+
+	// TotalRows is number of rows of the whole query result.
+	TotalRows uint64
+}
+
+// queryPageToken is content of an encoded page token.
+type queryPageToken struct {
+	JobID     string `json:"j"`
+	Location  string `json:"l"`
+	Offset    uint64 `json:"o"`
+	QueryHash uint64 `json:"q"`
+}
+
+// pageTokenKey keep the client key used to sign page tokens, shared by all copies of the client.
+type pageTokenKey struct {
+	mutex sync.RWMutex
+	key   []byte
+}
+
+// SetPageTokenKey set the client key used to sign and verify page tokens of RunQueryPage,
+// used when PageTokenKey of config is not set. Set the same key on all replicas.
+func (q BigQuery) SetPageTokenKey(key []byte) error {
+	if len(key) == 0 {
+		return ErrInvalidPageTokenKey
+	}
+
+	q.pageToken.mutex.Lock()
+	defer q.pageToken.mutex.Unlock()
+
+	q.pageToken.key = append([]byte(nil), key...)
+	return nil
+}
+
+// pageTokenKeyOf return key of page tokens from config, or the client key when not set.
+func (q BigQuery) pageTokenKeyOf(c config.RunQueryConfig) ([]byte, error) {
+	if len(c.PageTokenKey) > 0 {
+		return c.PageTokenKey, nil
+	}
+
+	if q.pageToken != nil {
+		q.pageToken.mutex.RLock()
+		defer q.pageToken.mutex.RUnlock()
+
+		if len(q.pageToken.key) > 0 {
+			return q.pageToken.key, nil
+		}
+	}
+
+	return nil, ErrInvalidPageTokenKey
+}
+
+// signPageToken return HMAC-SHA256 of data.
+func signPageToken(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// RunQueryPage return a page of query result with at most pageSize rows.
+// Query is run when pageToken is empty, otherwise the page is read from cached result of the job encoded in the token,
+// so the next page could be fetched by other client without running the query again.
+// Page token is signed using PageTokenKey from config, or the key set by SetPageTokenKey, and bound to the query
+// and its parameters. A key is required, use the same key on all replicas. Tokens signed by other key are rejected.
+// Cached query result is kept by BigQuery for about 24 hours.
+func (q BigQuery) RunQueryPage(query string, pageSize int, pageToken string, cfg ...config.RunQueryConfig) (*QueryPage[map[string]bigquery.Value], error) {
+	return RunQueryPageInto[map[string]bigquery.Value](&q, query, pageSize, pageToken, cfg...)
+}
+
+// RunQueryPageInto return a page of query result decoded into a slice of T with at most pageSize rows.
+// See RunQueryPage for how page token is used.
+func RunQueryPageInto[T any](q *BigQuery, query string, pageSize int, pageToken string, cfg ...config.RunQueryConfig) (*QueryPage[T], error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	if pageSize <= 0 {
+		return nil, ErrInvalidPageSize
+	}
+
+	// Get config from parameter
+	c := config.InitRunQueryConfig(cfg...)
+
+	// Fail before running the query when tokens could not be signed
+	key, err := q.pageTokenKeyOf(c)
+	if err != nil {
+		return nil, err
+	}
+
+	queryHash, err := hashQuery(query, c.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	// Run the query on the first page, otherwise attach to the job from page token
+	var job *bigquery.Job
+	var token queryPageToken
+	if pageToken == "" {
+		job, err = q.runQueryJob(ctx, q.newQuery(query, c.Parameters, c.Labels), c, q.retryPolicy(c.RetryPolicy, c.Retry, c.Delay))
+		if err != nil {
+			return nil, err
+		}
+
+		token = queryPageToken{JobID: job.ID(), Location: job.Location(), QueryHash: queryHash}
+	} else {
+		if token, err = decodePageToken(pageToken, key); err != nil {
+			return nil, err
+		}
+
+		if token.QueryHash != queryHash {
+			return nil, fmt.Errorf("%w: token belongs to another query", ErrInvalidPageToken)
+		}
+
+		job, err = q.client.JobFromIDLocation(ctx, token.JobID, token.Location)
+		if err != nil {
+			return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+		}
+	}
+
+	queryIterator, err := job.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	queryIterator.StartIndex = token.Offset
+	queryIterator.PageInfo().MaxSize = pageSize
+
+	page := &QueryPage[T]{}
+	for len(page.Rows) < pageSize {
+		var r T
+		err = queryIterator.Next(&r)
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+		}
+
+		page.Rows = append(page.Rows, r)
+	}
+
+	page.TotalRows = queryIterator.TotalRows
+	token.Offset += uint64(len(page.Rows))
+	if len(page.Rows) > 0 && token.Offset < page.TotalRows {
+		page.NextPageToken = token.encode(key)
+	}
+
+	return page, nil
+}
+
+// encode return the token as its base64 encoded content followed by the signature.
+func (t queryPageToken) encode(key []byte) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(signPageToken(key, data))
+}
+
+func decodePageToken(s string, key []byte) (queryPageToken, error) {
+	var t queryPageToken
+	encoded, encodedSignature, found := strings.Cut(s, ".")
+	if !found {
+		return t, fmt.Errorf("%w: missing signature", ErrInvalidPageToken)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return t, fmt.Errorf(errorWrapper, ErrInvalidPageToken, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return t, fmt.Errorf(errorWrapper, ErrInvalidPageToken, err)
+	}
+
+	if !hmac.Equal(signature, signPageToken(key, data)) {
+		return t, fmt.Errorf("%w: invalid signature", ErrInvalidPageToken)
+	}
+
+	if err = json.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf(errorWrapper, ErrInvalidPageToken, err)
+	}
+
+	if t.JobID == "" {
+		return t, fmt.Errorf("%w: missing job id", ErrInvalidPageToken)
+	}
+
+	return t, nil
+}
+
+// hashQuery return hash of a query and its parameters, used to reject page token of another query.
+func hashQuery(query string, params config.Parameters) (uint64, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(query))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(data)
+	return h.Sum64(), nil
+}
@@ -0,0 +1,107 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"errors"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"testing"
+)
+
+func TestPageTokenKeyOf(t *testing.T) {
+	q := BigQuery{pageToken: &pageTokenKey{}}
+	if _, err := q.pageTokenKeyOf(config.RunQueryConfig{}); !errors.Is(err, ErrInvalidPageTokenKey) {
+		t.Fatalf("pageTokenKeyOf() without key error = %v, want %v", err, ErrInvalidPageTokenKey)
+	}
+
+	if err := q.SetPageTokenKey([]byte("client")); err != nil {
+		t.Fatalf("SetPageTokenKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		c    config.RunQueryConfig
+		want string
+	}{
+		{name: "client key", c: config.RunQueryConfig{}, want: "client"},
+		{name: "config key", c: config.RunQueryConfig{PageTokenKey: []byte("config")}, want: "config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := q.pageTokenKeyOf(tt.c)
+			if err != nil || string(key) != tt.want {
+				t.Errorf("pageTokenKeyOf() = %q, %v, want %q", key, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePageToken(t *testing.T) {
+	key := []byte("shared")
+	token := queryPageToken{JobID: "job", Location: "US", Offset: 10, QueryHash: 42}
+	encoded := token.encode(key)
+
+	tests := []struct {
+		name    string
+		token   string
+		key     []byte
+		wantErr bool
+	}{
+		{name: "same key on other replica", token: encoded, key: []byte("shared")},
+		{name: "other key", token: encoded, key: []byte("other"), wantErr: true},
+		{name: "tampered content", token: queryPageToken{JobID: "other", QueryHash: 42}.encode([]byte("forged")), key: key, wantErr: true},
+		{name: "missing signature", token: "e30", key: key, wantErr: true},
+		{name: "invalid encoding", token: "!.!", key: key, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePageToken(tt.token, tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPageToken) {
+					t.Errorf("decodePageToken() error = %v, want %v", err, ErrInvalidPageToken)
+				}
+
+				return
+			}
+
+			if err != nil || got != token {
+				t.Errorf("decodePageToken() = %+v, %v, want %+v", got, err, token)
+			}
+		})
+	}
+}
+
+func TestHashQuery(t *testing.T) {
+	query := "SELECT * FROM t WHERE id = @id"
+	base, err := hashQuery(query, config.Parameters{{Name: "id", Value: 1}})
+	if err != nil {
+		t.Fatalf("hashQuery() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		params config.Parameters
+		same   bool
+	}{
+		{name: "same query and parameters", query: query, params: config.Parameters{{Name: "id", Value: 1}}, same: true},
+		{name: "other parameter value", query: query, params: config.Parameters{{Name: "id", Value: 2}}},
+		{name: "without parameters", query: query},
+		{name: "other query", query: "SELECT 1", params: config.Parameters{{Name: "id", Value: 1}}},
+		{name: "other parameter type", query: query, params: config.Parameters{{Name: "id", Value: bigquery.NullInt64{}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hashQuery(tt.query, tt.params)
+			if err != nil {
+				t.Fatalf("hashQuery() error = %v", err)
+			}
+
+			if (got == base) != tt.same {
+				t.Errorf("hashQuery() same = %v, want %v", got == base, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"errors"
+	"github.com/tiketdatarisal/gcp/shared"
+	"time"
+)
+
+func init() {
+	shared.RegisterRetryClassifier(classifyJobError)
+}
+
+// classifyJobError classify errors of failed BigQuery jobs by their reason.
+func classifyJobError(err error) (bool, bool) {
+	var jobErr *bigquery.Error
+	if errors.As(err, &jobErr) {
+		return shared.IsRetryableReason(jobErr.Reason), true
+	}
+
+	return false, false
+}
+
+// SetRetryPolicy set retry policy of the client, used by calls without their own retry policy.
+// Retry and Delay of a config override max attempts and initial delay of the client policy.
+func (q BigQuery) SetRetryPolicy(policy shared.RetryPolicy) {
+	q.retrier.SetPolicy(policy)
+}
+
+// retryPolicy return retry policy of a config when set.
+// Otherwise return client retry policy, with number of retries and delay of the config.
+func (q BigQuery) retryPolicy(policy shared.RetryPolicy, retry int, delay time.Duration) shared.RetryPolicy {
+	if policy.MaxAttempts > 0 {
+		return shared.InitRetryPolicy(policy)
+	}
+
+	p := q.retrier.Policy()
+	p.MaxAttempts = retry + 1
+	if delay > 0 {
+		p.InitialDelay = delay
+	}
+
+	return p
+}
@@ -3,8 +3,14 @@ package bigquery
 import (
 	"cloud.google.com/go/bigquery"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/tiketdatarisal/gcp/bigquery/config"
-	"time"
+	"github.com/tiketdatarisal/gcp/shared"
+	"google.golang.org/api/googleapi"
+	"net/http"
 )
 
 // RunQueryToCSV query and store the result to CSV file.
@@ -12,6 +18,45 @@ import (
 // For example: gcsURI = "gs://bucket/sample-*.csv" will save to "sample-000000000000.csv",
 // "sample-000000000001.csv", etc.
 func (q BigQuery) RunQueryToCSV(query, gcsURI string, cfg ...config.RunQueryConfig) error {
+	c := config.InitRunQueryConfig(cfg...)
+	c.Format = bigquery.CSV
+	return q.RunQueryToGCS(query, gcsURI, c)
+}
+
+// RunQueryToJSON query and store the result to JSON file.
+// Use wildcard (*) when you want to save to multiple files.
+// For example: gcsURI = "gs://bucket/sample-*.json" will save to "sample-000000000000.json",
+// "sample-000000000001.json", etc.
+func (q BigQuery) RunQueryToJSON(query, gcsURI string, cfg ...config.RunQueryConfig) error {
+	c := config.InitRunQueryConfig(cfg...)
+	c.Format = bigquery.JSON
+	return q.RunQueryToGCS(query, gcsURI, c)
+}
+
+// RunQueryToParquet query and store the result to Parquet file.
+// Use wildcard (*) when you want to save to multiple files.
+// For example: gcsURI = "gs://bucket/sample-*.parquet" will save to "sample-000000000000.parquet",
+// "sample-000000000001.parquet", etc.
+func (q BigQuery) RunQueryToParquet(query, gcsURI string, cfg ...config.RunQueryConfig) error {
+	c := config.InitRunQueryConfig(cfg...)
+	c.Format = bigquery.Parquet
+	return q.RunQueryToGCS(query, gcsURI, c)
+}
+
+// RunQueryToAvro query and store the result to Avro file.
+// Use wildcard (*) when you want to save to multiple files.
+// For example: gcsURI = "gs://bucket/sample-*.avro" will save to "sample-000000000000.avro",
+// "sample-000000000001.avro", etc.
+func (q BigQuery) RunQueryToAvro(query, gcsURI string, cfg ...config.RunQueryConfig) error {
+	c := config.InitRunQueryConfig(cfg...)
+	c.Format = bigquery.Avro
+	return q.RunQueryToGCS(query, gcsURI, c)
+}
+
+// RunQueryToGCS query and store the result to file(s) with format from config.
+// Supported formats are CSV, JSON (newline-delimited), Parquet and Avro.
+// Use wildcard (*) when you want to save to multiple files.
+func (q BigQuery) RunQueryToGCS(query, gcsURI string, cfg ...config.RunQueryConfig) error {
 	if query == "" || gcsURI == "" {
 		return nil
 	}
@@ -19,192 +64,221 @@ func (q BigQuery) RunQueryToCSV(query, gcsURI string, cfg ...config.RunQueryConf
 	// Get config from parameter
 	c := config.InitRunQueryConfig(cfg...)
 
-	// Initialize context with timeout when possible
-	ctx := q.ctx
-	var cancel context.CancelFunc
-	if c.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(q.ctx, c.Timeout)
-		defer func() {
-			if cancel != nil {
-				cancel()
-			}
-		}()
-	}
-
-	// Initialize task with labels when possible
-	task := q.client.Query(query)
-	if c.Labels != nil {
-		task.Labels = c.Labels
-	}
-
-	// Run the query job and wait for result
-	result, err := task.Run(ctx)
+	// Prepare destination reference before running the query, so invalid format fails early
+	gcsRef, err := newExportReference(gcsURI, c)
 	if err != nil {
 		return err
 	}
 
-	status, err := result.Wait(ctx)
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	// Initialize task with parameters and labels when possible
+	task := q.newQuery(query, c.Parameters, c.Labels)
+
+	// Run the query job within budget and wait for result
+	policy := q.retryPolicy(c.RetryPolicy, c.Retry, c.Delay)
+	result, err := q.runQueryJob(ctx, task, c, policy)
 	if err != nil {
 		return err
-	} else if err := status.Err(); err != nil {
-		return err
 	}
 
 	// Get temporary table from the result
-	resConfig, err := result.Config()
+	tmpTable, err := queryDestination(result)
 	if err != nil {
 		return err
 	}
 
-	var tmpTable *bigquery.Table
-	if queryConfig, ok := resConfig.(*bigquery.QueryConfig); ok {
-		tmpTable = queryConfig.Dst
-	}
-
-	if tmpTable == nil {
-		return ErrTemporaryTableNotFound
+	// Prepare to export, initialize table extractor
+	extractor := tmpTable.ExtractorTo(gcsRef)
+	if c.Format == bigquery.CSV {
+		extractor.DisableHeader = c.DisableHeader
 	}
 
-	// Prepare to export, initialize table extractor
-	gcsRef := bigquery.NewGCSReference(gcsURI)
-	gcsRef.DestinationFormat = bigquery.CSV
-	gcsRef.FieldDelimiter = c.Delimiter
-	if c.Compressed {
-		gcsRef.Compression = bigquery.Gzip
+	if c.Format == bigquery.Avro {
+		extractor.UseAvroLogicalTypes = c.UseAvroLogicalTypes
 	}
 
-	extractor := tmpTable.ExtractorTo(gcsRef)
-	extractor.DisableHeader = c.DisableHeader
 	if c.Labels != nil {
 		extractor.Labels = c.Labels
 	}
 
-	retry := c.Retry
-	var extractErr error = nil
-	for {
-		extractErr := func() error {
-			result, err := extractor.Run(ctx)
-			if err != nil {
-				return err
-			}
-
-			status, err := result.Wait(ctx)
-			if err != nil {
-				return err
-			} else if err := status.Err(); err != nil {
-				return err
-			}
-
-			return nil
-		}()
-
-		if extractErr != nil && retry > 0 {
-			time.Sleep(c.Delay)
-			retry--
-		} else {
-			break
-		}
+	_, err = q.runJob(ctx, &extractor.JobIDConfig, policy, func() (*bigquery.Job, error) {
+		return extractor.Run(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrExportFailed, err)
 	}
 
-	return extractErr
+	return nil
 }
 
-// RunQueryToJSON query and store the result to JSON file.
-// Use wildcard (*) when you want to save to multiple files.
-// For example: gcsURI = "gs://bucket/sample-*.json" will save to "sample-000000000000.json",
-// "sample-000000000001.json", etc.
-func (q BigQuery) RunQueryToJSON(query, gcsURI string, cfg ...config.RunQueryConfig) error {
-	if query == "" || gcsURI == "" {
-		return nil
+// queryDestination return destination table of a finished query job, a temporary table when not set.
+func queryDestination(job *bigquery.Job) (*bigquery.Table, error) {
+	jobConfig, err := job.Config()
+	if err != nil {
+		return nil, err
 	}
 
-	// Get config from parameter
-	c := config.InitRunQueryConfig(cfg...)
+	var table *bigquery.Table
+	if queryConfig, ok := jobConfig.(*bigquery.QueryConfig); ok {
+		table = queryConfig.Dst
+	}
 
-	// Initialize context with timeout when possible
-	ctx := q.ctx
-	var cancel context.CancelFunc
-	if c.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(q.ctx, c.Timeout)
-		defer func() {
-			if cancel != nil {
-				cancel()
-			}
-		}()
+	if table == nil {
+		return nil, ErrTemporaryTableNotFound
 	}
 
-	// Initialize task with labels when possible
-	task := q.client.Query(query)
-	if c.Labels != nil {
-		task.Labels = c.Labels
+	return table, nil
+}
+
+// newExportReference return a GCS reference with destination format and compression from config.
+func newExportReference(gcsURI string, c config.RunQueryConfig) (*bigquery.GCSReference, error) {
+	format := c.Format
+	if format == "" {
+		format = bigquery.CSV
 	}
 
-	// Run the query job and wait for result
-	result, err := task.Run(ctx)
+	compression, err := exportCompression(format, c)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	status, err := result.Wait(ctx)
-	if err != nil {
-		return err
-	} else if err := status.Err(); err != nil {
-		return err
+	gcsRef := bigquery.NewGCSReference(gcsURI)
+	gcsRef.DestinationFormat = format
+	if format == bigquery.CSV {
+		gcsRef.FieldDelimiter = c.Delimiter
 	}
 
-	// Get temporary table from the result
-	resConfig, err := result.Config()
-	if err != nil {
-		return err
+	if compression != "" {
+		gcsRef.Compression = compression
 	}
 
-	var tmpTable *bigquery.Table
-	if queryConfig, ok := resConfig.(*bigquery.QueryConfig); ok {
-		tmpTable = queryConfig.Dst
+	return gcsRef, nil
+}
+
+// exportCompression return compression of exported data from config, validated against the format.
+// Return empty compression when the data will not be compressed.
+func exportCompression(format bigquery.DataFormat, c config.RunQueryConfig) (bigquery.Compression, error) {
+	supported, exists := config.ExportCompressions[format]
+	if !exists {
+		return "", fmt.Errorf(errorWrapper, ErrUnsupportedExportFormat, format)
 	}
 
-	if tmpTable == nil {
-		return ErrTemporaryTableNotFound
+	compression := c.Codec
+	if compression == "" && c.Compressed {
+		compression = supported[0]
 	}
 
-	// Prepare to export, initialize table extractor
-	gcsRef := bigquery.NewGCSReference(gcsURI)
-	gcsRef.DestinationFormat = bigquery.JSON
-	if c.Compressed {
-		gcsRef.Compression = bigquery.Gzip
+	if compression != "" && compression != bigquery.None && !containsCompression(supported, compression) {
+		return "", fmt.Errorf("%w: %s for %s", ErrUnsupportedCompression, compression, format)
 	}
 
-	extractor := tmpTable.ExtractorTo(gcsRef)
-	if c.Labels != nil {
-		extractor.Labels = c.Labels
+	if compression == bigquery.None {
+		return "", nil
 	}
 
-	retry := c.Retry
-	var extractErr error = nil
-	for {
-		extractErr := func() error {
-			result, err := extractor.Run(ctx)
-			if err != nil {
-				return err
+	return compression, nil
+}
+
+func containsCompression(compressions []bigquery.Compression, compression bigquery.Compression) bool {
+	for _, c := range compressions {
+		if c == compression {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runJob run a job and wait for it to finish, using retry policy:
+//   - submission failed with a retryable error is retried with the same job ID, so the job is never run twice,
+//   - waiting failed with a retryable error is retried by polling the same job again,
+//   - a new job is only started when the job itself failed with a retryable reason, e.g. backendError.
+//
+// Job ID of the job config is generated for each job. Return the job of the last attempt, its status is available from LastStatus.
+func (q BigQuery) runJob(ctx context.Context, jobConfig *bigquery.JobIDConfig, policy shared.RetryPolicy, run func() (*bigquery.Job, error)) (*bigquery.Job, error) {
+	return q.retryJob(ctx, jobConfig, policy, true, run)
+}
+
+// submitJob submit a job without waiting for it, submission is retried with the same job ID as described in runJob.
+func (q BigQuery) submitJob(ctx context.Context, jobConfig *bigquery.JobIDConfig, policy shared.RetryPolicy, run func() (*bigquery.Job, error)) (*bigquery.Job, error) {
+	return q.retryJob(ctx, jobConfig, policy, false, run)
+}
+
+// retryJob run a job using retry policy, waiting for it to finish when wait is true.
+func (q BigQuery) retryJob(ctx context.Context, jobConfig *bigquery.JobIDConfig, policy shared.RetryPolicy, wait bool, run func() (*bigquery.Job, error)) (*bigquery.Job, error) {
+	policy = shared.InitRetryPolicy(policy)
+	jobConfig.JobID = newJobID()
+	jobConfig.AddJobIDSuffix = false
+
+	var job, last *bigquery.Job
+	var submitted bool
+	err := policy.Do(ctx, func() error {
+		var err error
+		if job == nil {
+			job, err = run()
+			if err != nil && submitted && isAlreadyExists(err) {
+				// The previous submission has created the job, although it failed
+				job, err = q.client.JobFromIDLocation(ctx, jobConfig.JobID, jobConfig.Location)
 			}
 
-			status, err := result.Wait(ctx)
+			submitted = true
 			if err != nil {
-				return err
-			} else if err := status.Err(); err != nil {
+				job = nil
 				return err
 			}
 
+			last = job
+		}
+
+		if !wait {
 			return nil
-		}()
+		}
+
+		status, err := job.Wait(ctx)
+		if err != nil {
+			// Poll the same job on the next attempt
+			if polled, pollErr := q.client.JobFromIDLocation(ctx, job.ID(), job.Location()); pollErr == nil {
+				job, last = polled, polled
+			}
 
-		if extractErr != nil && retry > 0 {
-			time.Sleep(c.Delay)
-			retry--
-		} else {
-			break
+			return err
 		}
-	}
 
-	return extractErr
+		q.recordBytesBilled(status)
+		if err = status.Err(); err != nil {
+			// Failed job could only be retried as a new job
+			if policy.IsRetryable(err) {
+				jobConfig.JobID = newJobID()
+				submitted = false
+				job = nil
+			}
+
+			return err
+		}
+
+		return nil
+	})
+
+	return last, err
+}
+
+// newJobID return a random job ID, generated before submission so the submission could be retried.
+func newJobID() string {
+	return "gcp_" + randomID()
+}
+
+// randomID return a random hex string, used as ID of jobs and inserted rows.
+func randomID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isAlreadyExists return true when a job could not be created because its ID is already used.
+func isAlreadyExists(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
 }
@@ -0,0 +1,90 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"google.golang.org/api/iterator"
+	"time"
+)
+
+// RunQueryInto return query result decoded into a slice of T when succeeded.
+// T is usually a struct, its fields are mapped to columns using `bigquery` tags.
+// Nested RECORD and REPEATED columns are decoded into nested structs and slices,
+// NULL-able columns into bigquery.NullXXX types, and DATE, TIME or DATETIME columns into civil types.
+func RunQueryInto[T any](q *BigQuery, query string, labels map[string]string, timeout ...time.Duration) ([]T, error) {
+	return RunQueryIntoWithParams[T](q, query, nil, labels, timeout...)
+}
+
+// RunQueryIntoWithParams return parameterized query result decoded into a slice of T when succeeded.
+// Use @name placeholders with named parameters, or ? placeholders with positional (unnamed) parameters.
+func RunQueryIntoWithParams[T any](q *BigQuery, query string, params config.Parameters, labels map[string]string, timeout ...time.Duration) ([]T, error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	var result []T
+	err := RunQueryFuncIntoWithParams(q, query, params, labels, func(row T) error {
+		result = append(result, row)
+		return nil
+	}, timeout...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RunQueryFuncInto query and process the query result decoded into T in func.
+func RunQueryFuncInto[T any](q *BigQuery, query string, labels map[string]string, f func(row T) error, timeout ...time.Duration) error {
+	return RunQueryFuncIntoWithParams(q, query, nil, labels, f, timeout...)
+}
+
+// RunQueryFuncIntoWithParams run a parameterized query and process the query result decoded into T in func.
+// Use @name placeholders with named parameters, or ? placeholders with positional (unnamed) parameters.
+func RunQueryFuncIntoWithParams[T any](q *BigQuery, query string, params config.Parameters, labels map[string]string, f func(row T) error, timeout ...time.Duration) error {
+	if query == "" || f == nil {
+		return nil
+	}
+
+	queryIterator, cancel, err := q.readQuery(query, params, labels, timeout...)
+	defer cancel()
+	if err != nil {
+		return err
+	}
+
+	if err = iterateRows(queryIterator, f); err != nil {
+		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	return nil
+}
+
+// iterateRows decode each row into T and process it in func.
+// Iteration stops without error when the function return iterator.Done.
+func iterateRows[T any](queryIterator *bigquery.RowIterator, f func(row T) error) error {
+	for {
+		// Get next row, when possible
+		var r T
+		err := queryIterator.Next(&r)
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Break the loop when the function return iterator.Done
+		err = f(r)
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,544 @@
+package bigquery
+
+import (
+	"bytes"
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"google.golang.org/api/iterator"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/apache/arrow/go/v10/parquet"
+	"github.com/apache/arrow/go/v10/parquet/compress"
+	"github.com/apache/arrow/go/v10/parquet/pqarrow"
+)
+
+const timestampLayout = "2006-01-02 15:04:05.999999 UTC"
+
+// rowEncoder encode rows of a query result into a writer.
+type rowEncoder interface {
+	encode(row []bigquery.Value) error
+	close() error
+}
+
+// RunQueryToWriter query and encode the result into a writer, without staging the data in GCS.
+// Supported formats are bigquery.CSV, bigquery.JSON (newline-delimited) and bigquery.Parquet,
+// format from config is used when format is empty.
+// Delimiter, DisableHeader, Compressed and Codec from config are honored, see ExportCompressions for supported codecs.
+// Nested RECORD and REPEATED columns are written as JSON in CSV, as objects and arrays in JSON,
+// and as groups and repeated fields in Parquet.
+// BYTES columns are written as base64, NUMERIC and BIGNUMERIC columns as decimal strings.
+// NaN and infinite FLOAT values are written as NaN, Infinity and -Infinity in CSV, and as null in JSON.
+// MaxBytesBilled, DryRunFirst, RetryPolicy, Retry and Delay from config are honored as in RunQueryToGCS.
+func (q BigQuery) RunQueryToWriter(query string, w io.Writer, format bigquery.DataFormat, cfg ...config.RunQueryConfig) error {
+	if query == "" || w == nil {
+		return nil
+	}
+
+	// Get config from parameter
+	c := config.InitRunQueryConfig(cfg...)
+	if format != "" {
+		c.Format = format
+	}
+
+	if c.Format == bigquery.Avro {
+		return fmt.Errorf(errorWrapper, ErrUnsupportedExportFormat, c.Format)
+	}
+
+	// Validate compression and delimiter before running the query, so invalid config fails early
+	compression, err := exportCompression(c.Format, c)
+	if err != nil {
+		return err
+	}
+
+	if utf8.RuneCountInString(c.Delimiter) > 1 {
+		return fmt.Errorf(errorWrapper, ErrExportFailed, "delimiter must be a single character")
+	}
+
+	task := q.newQuery(query, c.Parameters, c.Labels)
+	queryIterator, cancel, err := q.readQueryJob(task, c, q.retryPolicy(c.RetryPolicy, c.Retry, c.Delay), c.Timeout)
+	defer cancel()
+	if err != nil {
+		return err
+	}
+
+	// Schema of the result is only known once the first row has been fetched
+	var row []bigquery.Value
+	err = queryIterator.Next(&row)
+	if err != nil && err != iterator.Done {
+		return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+	}
+
+	hasRow := err == nil
+	encoder, err := newRowEncoder(w, queryIterator.Schema, compression, c)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrExportFailed, err)
+	}
+
+	for hasRow {
+		if err = encoder.encode(row); err != nil {
+			_ = encoder.close()
+			return fmt.Errorf(errorWrapper, ErrExportFailed, err)
+		}
+
+		row = nil
+		err = queryIterator.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			_ = encoder.close()
+			return fmt.Errorf(errorWrapper, ErrRunQueryFailed, err)
+		}
+	}
+
+	if err = encoder.close(); err != nil {
+		return fmt.Errorf(errorWrapper, ErrExportFailed, err)
+	}
+
+	return nil
+}
+
+// newRowEncoder return an encoder of a format from config, compressed when possible.
+func newRowEncoder(w io.Writer, schema bigquery.Schema, compression bigquery.Compression, c config.RunQueryConfig) (rowEncoder, error) {
+	if c.Format == bigquery.Parquet {
+		return newParquetEncoder(w, schema, compression)
+	}
+
+	// CSV and JSON are compressed as a whole with GZIP
+	var gz *gzip.Writer
+	if compression == bigquery.Gzip {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	if c.Format == bigquery.JSON {
+		return &jsonEncoder{gz: gz, encoder: json.NewEncoder(w), schema: schema}, nil
+	}
+
+	return newCSVEncoder(w, gz, schema, c)
+}
+
+// csvEncoder encode rows as CSV, nested and repeated values are encoded as JSON.
+type csvEncoder struct {
+	gz     *gzip.Writer
+	writer *csv.Writer
+	schema bigquery.Schema
+}
+
+func newCSVEncoder(w io.Writer, gz *gzip.Writer, schema bigquery.Schema, c config.RunQueryConfig) (*csvEncoder, error) {
+	writer := csv.NewWriter(w)
+	if c.Delimiter != "" {
+		delimiter, _ := utf8.DecodeRuneInString(c.Delimiter)
+		writer.Comma = delimiter
+	}
+
+	e := &csvEncoder{gz: gz, writer: writer, schema: schema}
+	if !c.DisableHeader {
+		header := make([]string, len(schema))
+		for i, field := range schema {
+			header[i] = field.Name
+		}
+
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+func (e *csvEncoder) encode(row []bigquery.Value) error {
+	record := make([]string, len(e.schema))
+	for i, field := range e.schema {
+		if i >= len(row) {
+			break
+		}
+
+		if s, ok := nonFiniteString(row[i]); ok {
+			record[i] = s
+			continue
+		}
+
+		value := jsonValue(row[i], field)
+		switch v := value.(type) {
+		case nil:
+		case string:
+			record[i] = v
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			record[i] = string(data)
+		}
+	}
+
+	return e.writer.Write(record)
+}
+
+func (e *csvEncoder) close() error {
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return err
+	}
+
+	if e.gz != nil {
+		return e.gz.Close()
+	}
+
+	return nil
+}
+
+// jsonEncoder encode rows as newline-delimited JSON, keeping order of columns.
+type jsonEncoder struct {
+	gz      *gzip.Writer
+	encoder *json.Encoder
+	schema  bigquery.Schema
+}
+
+func (e *jsonEncoder) encode(row []bigquery.Value) error {
+	return e.encoder.Encode(jsonValue(row, &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Schema: e.schema}))
+}
+
+func (e *jsonEncoder) close() error {
+	if e.gz != nil {
+		return e.gz.Close()
+	}
+
+	return nil
+}
+
+// jsonRecord is a record encoded as JSON object, keeping order of its fields.
+type jsonRecord struct {
+	names  []string
+	values []any
+}
+
+func (r jsonRecord) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range r.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonValue return a value of a field that could be encoded as JSON, formatted as BigQuery exports it.
+func jsonValue(v bigquery.Value, field *bigquery.FieldSchema) any {
+	if v == nil {
+		return nil
+	}
+
+	if field.Repeated {
+		items, _ := v.([]bigquery.Value)
+		element := *field
+		element.Repeated = false
+
+		values := make([]any, len(items))
+		for i, item := range items {
+			values[i] = jsonValue(item, &element)
+		}
+
+		return values
+	}
+
+	switch value := v.(type) {
+	case []bigquery.Value:
+		record := jsonRecord{}
+		for i, f := range field.Schema {
+			if i >= len(value) {
+				break
+			}
+
+			record.names = append(record.names, f.Name)
+			record.values = append(record.values, jsonValue(value[i], f))
+		}
+
+		return record
+	case time.Time:
+		return value.UTC().Format(timestampLayout)
+	case civil.Date, civil.Time, civil.DateTime:
+		return fmt.Sprint(value)
+	case *big.Rat:
+		if field.Type == bigquery.BigNumericFieldType {
+			return bigquery.BigNumericString(value)
+		}
+
+		return bigquery.NumericString(value)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value)
+	case float64:
+		// NaN and infinity could not be encoded as JSON number
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil
+		}
+	}
+
+	return v
+}
+
+// nonFiniteString return text of a NaN or infinite FLOAT value, as BigQuery exports it to CSV.
+func nonFiniteString(v bigquery.Value) (string, bool) {
+	value, ok := v.(float64)
+	switch {
+	case !ok:
+		return "", false
+	case math.IsNaN(value):
+		return "NaN", true
+	case math.IsInf(value, 1):
+		return "Infinity", true
+	case math.IsInf(value, -1):
+		return "-Infinity", true
+	}
+
+	return "", false
+}
+
+// parquetRowGroupRows is number of rows buffered in memory before they are written as a Parquet row group.
+const parquetRowGroupRows = 10000
+
+// parquetEncoder encode rows as Parquet, rows are buffered in memory until a row group is full.
+type parquetEncoder struct {
+	writer  *pqarrow.FileWriter
+	builder *array.RecordBuilder
+	schema  bigquery.Schema
+	rows    int
+}
+
+func newParquetEncoder(w io.Writer, schema bigquery.Schema, compression bigquery.Compression) (*parquetEncoder, error) {
+	codec := compress.Codecs.Uncompressed
+	switch compression {
+	case bigquery.Snappy:
+		codec = compress.Codecs.Snappy
+	case bigquery.Gzip:
+		codec = compress.Codecs.Gzip
+	case config.Zstd:
+		codec = compress.Codecs.Zstd
+	}
+
+	// Writer is hidden behind a plain io.Writer, so it is not closed with the Parquet file
+	arrowSchema := arrow.NewSchema(parquetFields(schema), nil)
+	props := parquet.NewWriterProperties(parquet.WithCompression(codec))
+	fw, err := pqarrow.NewFileWriter(arrowSchema, struct{ io.Writer }{w}, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+
+	return &parquetEncoder{
+		writer:  fw,
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, arrowSchema),
+		schema:  schema,
+	}, nil
+}
+
+// parquetFields return Arrow fields of a BigQuery schema, written as their matching Parquet types.
+func parquetFields(schema bigquery.Schema) []arrow.Field {
+	fields := make([]arrow.Field, 0, len(schema))
+	for _, field := range schema {
+		var dataType arrow.DataType
+		switch field.Type {
+		case bigquery.RecordFieldType:
+			dataType = arrow.StructOf(parquetFields(field.Schema)...)
+		case bigquery.IntegerFieldType:
+			dataType = arrow.PrimitiveTypes.Int64
+		case bigquery.FloatFieldType:
+			dataType = arrow.PrimitiveTypes.Float64
+		case bigquery.BooleanFieldType:
+			dataType = arrow.FixedWidthTypes.Boolean
+		case bigquery.TimestampFieldType:
+			dataType = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		case bigquery.DateFieldType:
+			dataType = arrow.FixedWidthTypes.Date32
+		case bigquery.TimeFieldType:
+			dataType = arrow.FixedWidthTypes.Time64us
+		default:
+			dataType = arrow.BinaryTypes.String
+		}
+
+		if field.Repeated {
+			dataType = arrow.ListOfNonNullable(dataType)
+		}
+
+		fields = append(fields, arrow.Field{Name: field.Name, Type: dataType, Nullable: !field.Required})
+	}
+
+	return fields
+}
+
+func (e *parquetEncoder) encode(row []bigquery.Value) error {
+	for i, field := range e.schema {
+		var value bigquery.Value
+		if i < len(row) {
+			value = row[i]
+		}
+
+		if err := appendParquetValue(e.builder.Field(i), value, field); err != nil {
+			return err
+		}
+	}
+
+	e.rows++
+	if e.rows >= parquetRowGroupRows {
+		return e.flush()
+	}
+
+	return nil
+}
+
+// flush write buffered rows as a row group.
+func (e *parquetEncoder) flush() error {
+	record := e.builder.NewRecord()
+	defer record.Release()
+
+	e.rows = 0
+	return e.writer.Write(record)
+}
+
+func (e *parquetEncoder) close() error {
+	defer e.builder.Release()
+
+	if e.rows > 0 {
+		if err := e.flush(); err != nil {
+			_ = e.writer.Close()
+			return err
+		}
+	}
+
+	return e.writer.Close()
+}
+
+// appendParquetValue append a value of a field to its Arrow builder, matching its Parquet type.
+func appendParquetValue(b array.Builder, v bigquery.Value, field *bigquery.FieldSchema) error {
+	if v == nil {
+		b.AppendNull()
+		return nil
+	}
+
+	if field.Repeated {
+		items, _ := v.([]bigquery.Value)
+		element := *field
+		element.Repeated = false
+
+		lb := b.(*array.ListBuilder)
+		lb.Append(true)
+		for _, item := range items {
+			if err := appendParquetValue(lb.ValueBuilder(), item, &element); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	var ok bool
+	switch builder := b.(type) {
+	case *array.StructBuilder:
+		var record []bigquery.Value
+		if record, ok = v.([]bigquery.Value); ok {
+			builder.Append(true)
+			for i, f := range field.Schema {
+				var item bigquery.Value
+				if i < len(record) {
+					item = record[i]
+				}
+
+				if err := appendParquetValue(builder.FieldBuilder(i), item, f); err != nil {
+					return err
+				}
+			}
+		}
+	case *array.Int64Builder:
+		var value int64
+		if value, ok = v.(int64); ok {
+			builder.Append(value)
+		}
+	case *array.Float64Builder:
+		var value float64
+		if value, ok = v.(float64); ok {
+			builder.Append(value)
+		}
+	case *array.BooleanBuilder:
+		var value bool
+		if value, ok = v.(bool); ok {
+			builder.Append(value)
+		}
+	case *array.TimestampBuilder:
+		var value time.Time
+		if value, ok = v.(time.Time); ok {
+			builder.Append(arrow.Timestamp(value.UnixMicro()))
+		}
+	case *array.Date32Builder:
+		var value civil.Date
+		if value, ok = v.(civil.Date); ok {
+			builder.Append(arrow.Date32(value.DaysSince(civilEpoch)))
+		}
+	case *array.Time64Builder:
+		var value civil.Time
+		if value, ok = v.(civil.Time); ok {
+			d := time.Duration(value.Hour)*time.Hour + time.Duration(value.Minute)*time.Minute +
+				time.Duration(value.Second)*time.Second + time.Duration(value.Nanosecond)
+			builder.Append(arrow.Time64(d.Microseconds()))
+		}
+	case *array.StringBuilder:
+		// Other types are written as text, formatted as in JSON
+		value := jsonValue(v, field)
+		if s, isString := value.(string); isString {
+			builder.Append(s)
+			return nil
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		builder.Append(string(data))
+		return nil
+	}
+
+	if !ok {
+		return fmt.Errorf("unexpected value %T of column %s", v, field.Name)
+	}
+
+	return nil
+}
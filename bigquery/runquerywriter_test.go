@@ -0,0 +1,111 @@
+package bigquery
+
+import (
+	"bytes"
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/apache/arrow/go/v10/parquet/file"
+	"github.com/apache/arrow/go/v10/parquet/pqarrow"
+)
+
+func TestParquetEncoder(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "day", Type: bigquery.DateFieldType},
+		{Name: "created", Type: bigquery.TimestampFieldType},
+		{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+		{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "city", Type: bigquery.StringFieldType},
+		}},
+	}
+
+	created := time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC)
+	rows := [][]bigquery.Value{
+		{int64(1), "a", civil.Date{Year: 1970, Month: time.January, Day: 2}, created, []bigquery.Value{"x", "y"}, []bigquery.Value{"Jakarta"}},
+		{int64(2), nil, nil, nil, []bigquery.Value{}, nil},
+	}
+
+	var buf bytes.Buffer
+	encoder, err := newParquetEncoder(&buf, schema, bigquery.Snappy)
+	if err != nil {
+		t.Fatalf("newParquetEncoder() = %v", err)
+	}
+
+	for _, row := range rows {
+		if err = encoder.encode(row); err != nil {
+			t.Fatalf("encode() = %v", err)
+		}
+	}
+
+	if err = encoder.close(); err != nil {
+		t.Fatalf("close() = %v", err)
+	}
+
+	reader, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewParquetReader() = %v", err)
+	}
+
+	fr, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("NewFileReader() = %v", err)
+	}
+
+	table, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("ReadTable() = %v", err)
+	}
+	defer table.Release()
+
+	if table.NumRows() != 2 || table.NumCols() != int64(len(schema)) {
+		t.Fatalf("table has %d rows and %d columns, want 2 and %d", table.NumRows(), table.NumCols(), len(schema))
+	}
+
+	ids := table.Column(0).Data().Chunk(0).(*array.Int64)
+	if ids.Value(0) != 1 || ids.Value(1) != 2 {
+		t.Errorf("id = %v, want [1 2]", ids)
+	}
+
+	names := table.Column(1).Data().Chunk(0).(*array.String)
+	if names.Value(0) != "a" || !names.IsNull(1) {
+		t.Errorf("name = %v, want [a (null)]", names)
+	}
+
+	days := table.Column(2).Data().Chunk(0).(*array.Date32)
+	if days.Value(0) != arrow.Date32(1) || !days.IsNull(1) {
+		t.Errorf("day = %v, want [1 (null)]", days)
+	}
+
+	timestamps := table.Column(3).Data().Chunk(0).(*array.Timestamp)
+	if timestamps.Value(0) != arrow.Timestamp(created.UnixMicro()) || !timestamps.IsNull(1) {
+		t.Errorf("created = %v, want [%d (null)]", timestamps, created.UnixMicro())
+	}
+
+	tags := table.Column(4).Data().Chunk(0).(*array.List)
+	values := tags.ListValues().(*array.String)
+	if offsets := tags.Offsets(); offsets[1] != 2 || offsets[2] != 2 || values.Value(0) != "x" || values.Value(1) != "y" {
+		t.Errorf("tags = %v, want [[x y] []]", tags)
+	}
+
+	address := table.Column(5).Data().Chunk(0).(*array.Struct)
+	if city := address.Field(0).(*array.String); city.Value(0) != "Jakarta" || !address.IsNull(1) {
+		t.Errorf("address = %v, want [{Jakarta} (null)]", address)
+	}
+}
+
+func TestAppendParquetValueUnexpectedType(t *testing.T) {
+	builder := array.NewInt64Builder(memory.DefaultAllocator)
+	defer builder.Release()
+
+	if err := appendParquetValue(builder, "1", &bigquery.FieldSchema{Name: "id", Type: bigquery.IntegerFieldType}); err == nil {
+		t.Errorf("appendParquetValue() = nil, want error")
+	}
+}
@@ -0,0 +1,187 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"fmt"
+	"strings"
+)
+
+const (
+	modeNullable = "NULLABLE"
+	modeRequired = "REQUIRED"
+	modeRepeated = "REPEATED"
+)
+
+// FieldChange is a change of a field between two schemas.
+// Old is nil for an added field, and New is nil for a removed field.
+type FieldChange struct {
+	// Path is dotted path of the field, for example "address.city".
+	Path string
+	Old  *bigquery.FieldSchema
+	New  *bigquery.FieldSchema
+}
+
+func (c FieldChange) String() string {
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("%s added as %s %s", c.Path, fieldMode(c.New), c.New.Type)
+	case c.New == nil:
+		return fmt.Sprintf("%s removed", c.Path)
+	case c.Old.Type != c.New.Type:
+		return fmt.Sprintf("%s type changed from %s to %s", c.Path, c.Old.Type, c.New.Type)
+	default:
+		return fmt.Sprintf("%s mode changed from %s to %s", c.Path, fieldMode(c.Old), fieldMode(c.New))
+	}
+}
+
+// SchemaDiff is differences between two schemas, including nested RECORD fields.
+type SchemaDiff struct {
+	Added       []FieldChange
+	Removed     []FieldChange
+	TypeChanged []FieldChange
+	ModeChanged []FieldChange
+}
+
+// IsEmpty return true when there is no difference.
+func (d SchemaDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.TypeChanged) == 0 && len(d.ModeChanged) == 0
+}
+
+// Incompatible return changes that could not be applied to an existing table.
+// Only new NULLABLE or REPEATED fields, and REQUIRED to NULLABLE relaxation could be applied.
+func (d SchemaDiff) Incompatible() []FieldChange {
+	var changes []FieldChange
+	for _, c := range d.Added {
+		if c.New.Required {
+			changes = append(changes, c)
+		}
+	}
+
+	changes = append(changes, d.Removed...)
+	changes = append(changes, d.TypeChanged...)
+	for _, c := range d.ModeChanged {
+		if fieldMode(c.Old) != modeRequired || fieldMode(c.New) != modeNullable {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// DiffSchema return differences from old schema to new schema.
+func DiffSchema(old, new bigquery.Schema) SchemaDiff {
+	var diff SchemaDiff
+	diffSchema(&diff, "", old, new)
+	return diff
+}
+
+func diffSchema(diff *SchemaDiff, prefix string, old, new bigquery.Schema) {
+	for _, oldField := range old {
+		path := prefix + oldField.Name
+		newField := findField(new, oldField.Name)
+		if newField == nil {
+			diff.Removed = append(diff.Removed, FieldChange{Path: path, Old: oldField})
+			continue
+		}
+
+		if oldField.Type != newField.Type {
+			diff.TypeChanged = append(diff.TypeChanged, FieldChange{Path: path, Old: oldField, New: newField})
+			continue
+		}
+
+		if fieldMode(oldField) != fieldMode(newField) {
+			diff.ModeChanged = append(diff.ModeChanged, FieldChange{Path: path, Old: oldField, New: newField})
+		}
+
+		if oldField.Type == bigquery.RecordFieldType {
+			diffSchema(diff, path+".", oldField.Schema, newField.Schema)
+		}
+	}
+
+	for _, newField := range new {
+		if findField(old, newField.Name) == nil {
+			diff.Added = append(diff.Added, FieldChange{Path: prefix + newField.Name, New: newField})
+		}
+	}
+}
+
+// findField return field of a schema by its name, ignoring case as BigQuery does.
+func findField(schema bigquery.Schema, name string) *bigquery.FieldSchema {
+	for _, field := range schema {
+		if strings.EqualFold(field.Name, name) {
+			return field
+		}
+	}
+
+	return nil
+}
+
+func fieldMode(field *bigquery.FieldSchema) string {
+	switch {
+	case field.Repeated:
+		return modeRepeated
+	case field.Required:
+		return modeRequired
+	default:
+		return modeNullable
+	}
+}
+
+// mergeSchema return old schema with modes relaxed and new fields appended, keeping order of existing fields.
+func mergeSchema(old, new bigquery.Schema) bigquery.Schema {
+	var merged bigquery.Schema
+	for _, oldField := range old {
+		field := *oldField
+		if newField := findField(new, oldField.Name); newField != nil {
+			if fieldMode(oldField) == modeRequired && fieldMode(newField) == modeNullable {
+				field.Required = false
+			}
+
+			if oldField.Type == bigquery.RecordFieldType {
+				field.Schema = mergeSchema(oldField.Schema, newField.Schema)
+			}
+		}
+
+		merged = append(merged, &field)
+	}
+
+	for _, newField := range new {
+		if findField(old, newField.Name) == nil {
+			merged = append(merged, newField)
+		}
+	}
+
+	return merged
+}
+
+// UpdateTableSchema apply safe changes of a schema to an existing table, and return the applied differences.
+// Only new NULLABLE or REPEATED fields, and REQUIRED to NULLABLE relaxation are applied, other changes are rejected.
+// The update fails when the table has been modified concurrently.
+func (q BigQuery) UpdateTableSchema(datasetID, tableID string, schema bigquery.Schema) (SchemaDiff, error) {
+	table := q.client.Dataset(datasetID).Table(tableID)
+	meta, err := table.Metadata(q.ctx)
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf(errorWrapper, ErrUpdateTableSchemaFailed, err)
+	}
+
+	diff := DiffSchema(meta.Schema, schema)
+	if incompatible := diff.Incompatible(); len(incompatible) > 0 {
+		var changes []string
+		for _, c := range incompatible {
+			changes = append(changes, c.String())
+		}
+
+		return diff, fmt.Errorf(errorWrapper, ErrIncompatibleSchemaChange, strings.Join(changes, "; "))
+	}
+
+	if diff.IsEmpty() {
+		return diff, nil
+	}
+
+	_, err = table.Update(q.ctx, bigquery.TableMetadataToUpdate{Schema: mergeSchema(meta.Schema, schema)}, meta.ETag)
+	if err != nil {
+		return diff, fmt.Errorf(errorWrapper, ErrUpdateTableSchemaFailed, err)
+	}
+
+	return diff, nil
+}
@@ -0,0 +1,141 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"reflect"
+	"testing"
+)
+
+func changeStrings(changes []FieldChange) []string {
+	var result []string
+	for _, c := range changes {
+		result = append(result, c.String())
+	}
+
+	return result
+}
+
+func TestDiffSchema(t *testing.T) {
+	address := func(fields ...*bigquery.FieldSchema) *bigquery.FieldSchema {
+		return &bigquery.FieldSchema{Name: "address", Type: bigquery.RecordFieldType, Schema: fields}
+	}
+
+	tests := []struct {
+		name         string
+		old, new     bigquery.Schema
+		added        []string
+		removed      []string
+		typeChanged  []string
+		modeChanged  []string
+		incompatible []string
+	}{
+		{
+			name: "same schema",
+			old:  bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}},
+			new:  bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}},
+		},
+		{
+			name: "field names ignore case",
+			old:  bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}},
+			new:  bigquery.Schema{{Name: "ID", Type: bigquery.IntegerFieldType}},
+		},
+		{
+			name:         "added fields",
+			old:          bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}},
+			new:          bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}, {Name: "name", Type: bigquery.StringFieldType}, {Name: "code", Type: bigquery.StringFieldType, Required: true}},
+			added:        []string{"name added as NULLABLE STRING", "code added as REQUIRED STRING"},
+			incompatible: []string{"code added as REQUIRED STRING"},
+		},
+		{
+			name:         "removed field",
+			old:          bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}, {Name: "name", Type: bigquery.StringFieldType}},
+			new:          bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}},
+			removed:      []string{"name removed"},
+			incompatible: []string{"name removed"},
+		},
+		{
+			name:         "type change",
+			old:          bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}},
+			new:          bigquery.Schema{{Name: "id", Type: bigquery.StringFieldType, Required: true}},
+			typeChanged:  []string{"id type changed from INTEGER to STRING"},
+			incompatible: []string{"id type changed from INTEGER to STRING"},
+		},
+		{
+			name: "mode changes",
+			old: bigquery.Schema{
+				{Name: "a", Type: bigquery.StringFieldType, Required: true},
+				{Name: "b", Type: bigquery.StringFieldType},
+				{Name: "c", Type: bigquery.StringFieldType},
+			},
+			new: bigquery.Schema{
+				{Name: "a", Type: bigquery.StringFieldType},
+				{Name: "b", Type: bigquery.StringFieldType, Required: true},
+				{Name: "c", Type: bigquery.StringFieldType, Repeated: true},
+			},
+			modeChanged: []string{
+				"a mode changed from REQUIRED to NULLABLE",
+				"b mode changed from NULLABLE to REQUIRED",
+				"c mode changed from NULLABLE to REPEATED",
+			},
+			incompatible: []string{
+				"b mode changed from NULLABLE to REQUIRED",
+				"c mode changed from NULLABLE to REPEATED",
+			},
+		},
+		{
+			name: "nested fields",
+			old: bigquery.Schema{address(
+				&bigquery.FieldSchema{Name: "city", Type: bigquery.StringFieldType, Required: true},
+				&bigquery.FieldSchema{Name: "zip", Type: bigquery.IntegerFieldType},
+				&bigquery.FieldSchema{Name: "street", Type: bigquery.StringFieldType},
+			)},
+			new: bigquery.Schema{address(
+				&bigquery.FieldSchema{Name: "city", Type: bigquery.StringFieldType},
+				&bigquery.FieldSchema{Name: "zip", Type: bigquery.StringFieldType},
+				&bigquery.FieldSchema{Name: "country", Type: bigquery.StringFieldType},
+			)},
+			added:        []string{"address.country added as NULLABLE STRING"},
+			removed:      []string{"address.street removed"},
+			typeChanged:  []string{"address.zip type changed from INTEGER to STRING"},
+			modeChanged:  []string{"address.city mode changed from REQUIRED to NULLABLE"},
+			incompatible: []string{"address.street removed", "address.zip type changed from INTEGER to STRING"},
+		},
+		{
+			name:         "record changed into other type is not compared deeper",
+			old:          bigquery.Schema{address(&bigquery.FieldSchema{Name: "city", Type: bigquery.StringFieldType})},
+			new:          bigquery.Schema{{Name: "address", Type: bigquery.StringFieldType}},
+			typeChanged:  []string{"address type changed from RECORD to STRING"},
+			incompatible: []string{"address type changed from RECORD to STRING"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffSchema(tt.old, tt.new)
+			if got := changeStrings(diff.Added); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("Added = %q, want %q", got, tt.added)
+			}
+
+			if got := changeStrings(diff.Removed); !reflect.DeepEqual(got, tt.removed) {
+				t.Errorf("Removed = %q, want %q", got, tt.removed)
+			}
+
+			if got := changeStrings(diff.TypeChanged); !reflect.DeepEqual(got, tt.typeChanged) {
+				t.Errorf("TypeChanged = %q, want %q", got, tt.typeChanged)
+			}
+
+			if got := changeStrings(diff.ModeChanged); !reflect.DeepEqual(got, tt.modeChanged) {
+				t.Errorf("ModeChanged = %q, want %q", got, tt.modeChanged)
+			}
+
+			if got := changeStrings(diff.Incompatible()); !reflect.DeepEqual(got, tt.incompatible) {
+				t.Errorf("Incompatible() = %q, want %q", got, tt.incompatible)
+			}
+
+			wantEmpty := tt.added == nil && tt.removed == nil && tt.typeChanged == nil && tt.modeChanged == nil
+			if diff.IsEmpty() != wantEmpty {
+				t.Errorf("IsEmpty() = %v, want %v", diff.IsEmpty(), wantEmpty)
+			}
+		})
+	}
+}
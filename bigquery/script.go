@@ -0,0 +1,260 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"github.com/tiketdatarisal/gcp/shared"
+	"google.golang.org/api/iterator"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scriptRetryPolicy disable retry of scripts, statements of a failed script could have been applied.
+var scriptRetryPolicy = shared.RetryPolicy{MaxAttempts: 1}
+
+// querySession is a BigQuery session shared by all copies of a BigQuery client.
+type querySession struct {
+	mutex sync.Mutex
+	id    string
+}
+
+// StatementResult is result of a statement in a script.
+type StatementResult struct {
+	JobID          string
+	StatementType  string
+	AffectedRows   int64
+	BytesProcessed int64
+
+	// Rows contains result of a SELECT statement.
+	Rows []map[string]bigquery.Value
+}
+
+// ScriptResult is result of a finished script.
+type ScriptResult struct {
+	JobID       string
+	SessionID   string
+	BytesBilled int64
+	Statements  []StatementResult
+}
+
+// RunScript run a multi-statement script within client budget and return result of each statement, in order.
+// Set UseSession to run the script inside the client session, so temporary tables survive across scripts.
+func (q BigQuery) RunScript(script string, cfg ...config.RunQueryConfig) (*ScriptResult, error) {
+	if script == "" {
+		return nil, nil
+	}
+
+	// Get config from parameter
+	c := config.InitRunQueryConfig(cfg...)
+
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	task := q.newQuery(script, c.Parameters, c.Labels)
+
+	var job *bigquery.Job
+	var err error
+	if c.UseSession && q.session != nil {
+		job, err = q.runSessionJob(ctx, task, c)
+	} else {
+		job, err = q.runQueryJob(ctx, task, c, scriptRetryPolicy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScriptResult{JobID: job.ID()}
+	stats := job.LastStatus().Statistics
+	if stats != nil {
+		if stats.SessionInfo != nil {
+			result.SessionID = stats.SessionInfo.SessionID
+		}
+
+		if queryStats, ok := stats.Details.(*bigquery.QueryStatistics); ok {
+			result.BytesBilled = queryStats.TotalBytesBilled
+		}
+	}
+
+	// Script with a single statement has no child job
+	var jobs []*bigquery.Job
+	if stats == nil || stats.NumChildJobs == 0 {
+		jobs = append(jobs, job)
+	} else if jobs, err = childJobs(ctx, job); err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrRunScriptFailed, err)
+	}
+
+	for _, j := range jobs {
+		statement, err := newStatementResult(ctx, j)
+		if err != nil {
+			return nil, fmt.Errorf(errorWrapper, ErrRunScriptFailed, err)
+		}
+
+		result.Statements = append(result.Statements, statement)
+	}
+
+	return result, nil
+}
+
+// runSessionJob run a query job inside the client session, the session is created by the first job.
+func (q BigQuery) runSessionJob(ctx context.Context, task *bigquery.Query, c config.RunQueryConfig) (*bigquery.Job, error) {
+	q.session.mutex.Lock()
+	defer q.session.mutex.Unlock()
+
+	if q.session.id != "" {
+		task.ConnectionProperties = []*bigquery.ConnectionProperty{{Key: "session_id", Value: q.session.id}}
+		return q.runQueryJob(ctx, task, c, scriptRetryPolicy)
+	}
+
+	task.CreateSession = true
+	job, err := q.runQueryJob(ctx, task, c, scriptRetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats := job.LastStatus().Statistics; stats != nil && stats.SessionInfo != nil {
+		q.session.id = stats.SessionInfo.SessionID
+	}
+
+	return job, nil
+}
+
+// SessionID return ID of the client session, empty when no session has been created.
+func (q BigQuery) SessionID() string {
+	if q.session == nil {
+		return ""
+	}
+
+	q.session.mutex.Lock()
+	defer q.session.mutex.Unlock()
+
+	return q.session.id
+}
+
+// EndSession terminate the client session and drop its temporary tables.
+// A new session will be created by the next script that use session.
+func (q BigQuery) EndSession() error {
+	if q.session == nil {
+		return nil
+	}
+
+	q.session.mutex.Lock()
+	defer q.session.mutex.Unlock()
+
+	if q.session.id == "" {
+		return nil
+	}
+
+	task := q.client.Query("CALL BQ.ABORT_SESSION()")
+	task.ConnectionProperties = []*bigquery.ConnectionProperty{{Key: "session_id", Value: q.session.id}}
+
+	job, err := task.Run(q.ctx)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrEndSessionFailed, err)
+	}
+
+	status, err := job.Wait(q.ctx)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrEndSessionFailed, err)
+	}
+
+	if err = status.Err(); err != nil {
+		return fmt.Errorf(errorWrapper, ErrEndSessionFailed, err)
+	}
+
+	q.session.id = ""
+	return nil
+}
+
+// childJobs return child jobs of a script job, ordered by creation time.
+func childJobs(ctx context.Context, job *bigquery.Job) ([]*bigquery.Job, error) {
+	var jobs []*bigquery.Job
+	jobIterator := job.Children(ctx)
+	for {
+		child, err := jobIterator.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, child)
+	}
+
+	// Statements could be created within the same millisecond, order them by their index in the job ID as well
+	sort.SliceStable(jobs, func(i, j int) bool {
+		ti, tj := jobCreationTime(jobs[i]), jobCreationTime(jobs[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+
+		ii, ij := statementIndex(jobs[i].ID()), statementIndex(jobs[j].ID())
+		if ii != ij {
+			return ii < ij
+		}
+
+		return jobs[i].ID() < jobs[j].ID()
+	})
+
+	return jobs, nil
+}
+
+// statementIndex return index of a statement from ID of its child job, formatted as "script_job_<hash>_<index>".
+// Return -1 when the ID has no index.
+func statementIndex(jobID string) int {
+	index, err := strconv.Atoi(jobID[strings.LastIndex(jobID, "_")+1:])
+	if err != nil {
+		return -1
+	}
+
+	return index
+}
+
+func jobCreationTime(job *bigquery.Job) time.Time {
+	if status := job.LastStatus(); status != nil && status.Statistics != nil {
+		return status.Statistics.CreationTime
+	}
+
+	return time.Time{}
+}
+
+// newStatementResult return result of a statement job, rows are read for SELECT statement.
+func newStatementResult(ctx context.Context, job *bigquery.Job) (StatementResult, error) {
+	result := StatementResult{JobID: job.ID()}
+	status := job.LastStatus()
+	if status == nil || status.Statistics == nil {
+		return result, nil
+	}
+
+	result.BytesProcessed = status.Statistics.TotalBytesProcessed
+	queryStats, ok := status.Statistics.Details.(*bigquery.QueryStatistics)
+	if !ok {
+		return result, nil
+	}
+
+	result.StatementType = queryStats.StatementType
+	result.AffectedRows = queryStats.NumDMLAffectedRows
+	if queryStats.StatementType != "SELECT" {
+		return result, nil
+	}
+
+	queryIterator, err := job.Read(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	type row = map[string]bigquery.Value
+	err = iterateRows(queryIterator, func(r row) error {
+		result.Rows = append(result.Rows, r)
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,188 @@
+package bigquery
+
+import (
+	"bytes"
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/civil"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+)
+
+// arrowDecoder decode record batches serialized as Arrow IPC messages.
+// Columns that are not read are omitted from the row.
+type arrowDecoder struct {
+	arrowSchema []byte
+	schema      bigquery.Schema
+}
+
+func newArrowDecoder(arrowSchema []byte, schema bigquery.Schema) (*arrowDecoder, error) {
+	if len(arrowSchema) == 0 {
+		return nil, fmt.Errorf("missing arrow schema")
+	}
+
+	return &arrowDecoder{arrowSchema: arrowSchema, schema: schema}, nil
+}
+
+func (d *arrowDecoder) decode(res *storagepb.ReadRowsResponse) ([]map[string]bigquery.Value, error) {
+	batch := res.GetArrowRecordBatch().GetSerializedRecordBatch()
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	// A record batch is only readable after its schema message
+	data := make([]byte, 0, len(d.arrowSchema)+len(batch))
+	data = append(data, d.arrowSchema...)
+	data = append(data, batch...)
+
+	reader, err := ipc.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Release()
+
+	var rows []map[string]bigquery.Value
+	for reader.Next() {
+		record := reader.Record()
+		columns := make([]arrow.Array, len(d.schema))
+		for i, field := range d.schema {
+			if indices := record.Schema().FieldIndices(field.Name); len(indices) > 0 {
+				columns[i] = record.Column(indices[0])
+			}
+		}
+
+		for i := 0; i < int(record.NumRows()); i++ {
+			row := make(map[string]bigquery.Value, len(d.schema))
+			for j, field := range d.schema {
+				if columns[j] == nil {
+					continue
+				}
+
+				value, err := arrowToValue(columns[j], i, field)
+				if err != nil {
+					return nil, err
+				}
+
+				row[field.Name] = value
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	if err = reader.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// arrowToValue convert a value of an Arrow array into a row value, using the same value types as RunQuery.
+func arrowToValue(arr arrow.Array, i int, field *bigquery.FieldSchema) (bigquery.Value, error) {
+	if arr.IsNull(i) {
+		return nil, nil
+	}
+
+	if field.Repeated {
+		list, ok := arr.(*array.List)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected list, got %s", field.Name, arr.DataType())
+		}
+
+		element := *field
+		element.Repeated = false
+
+		start, end := list.ValueOffsets(i)
+		values := make([]bigquery.Value, 0, end-start)
+		for j := start; j < end; j++ {
+			value, err := arrowToValue(list.ListValues(), int(j), &element)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return values, nil
+	}
+
+	switch a := arr.(type) {
+	case *array.Struct:
+		structType := a.DataType().(*arrow.StructType)
+		record := make(map[string]bigquery.Value, len(field.Schema))
+		for _, f := range field.Schema {
+			index, ok := structType.FieldIdx(f.Name)
+			if !ok {
+				continue
+			}
+
+			value, err := arrowToValue(a.Field(index), i, f)
+			if err != nil {
+				return nil, err
+			}
+
+			record[f.Name] = value
+		}
+
+		return record, nil
+	case *array.Int64:
+		return a.Value(i), nil
+	case *array.Float64:
+		return a.Value(i), nil
+	case *array.Boolean:
+		return a.Value(i), nil
+	case *array.String:
+		return a.Value(i), nil
+	case *array.Binary:
+		return append([]byte(nil), a.Value(i)...), nil
+	case *array.Decimal128:
+		return decimalToRat(a.Value(i).BigInt(), a.DataType().(*arrow.Decimal128Type).Scale), nil
+	case *array.Decimal256:
+		return decimalToRat(a.Value(i).BigInt(), a.DataType().(*arrow.Decimal256Type).Scale), nil
+	case *array.Date32:
+		return civil.DateOf(a.Value(i).ToTime()), nil
+	case *array.Time64:
+		d := time.Duration(a.Value(i)) * a.DataType().(*arrow.Time64Type).Unit.Multiplier()
+		return civil.TimeOf(time.Time{}.Add(d)), nil
+	case *array.Timestamp:
+		t := timestampToTime(int64(a.Value(i)), a.DataType().(*arrow.TimestampType).Unit)
+		if field.Type == bigquery.DateTimeFieldType {
+			return civil.DateTimeOf(t), nil
+		}
+
+		return t, nil
+	case *array.MonthDayNanoInterval:
+		v := a.Value(i)
+		interval := bigquery.IntervalValueFromDuration(time.Duration(v.Nanoseconds))
+		interval.Months = v.Months
+		interval.Days = v.Days
+		return interval.Canonicalize(), nil
+	}
+
+	return nil, fmt.Errorf("%s: unexpected %s for %s", field.Name, arr.DataType(), field.Type)
+}
+
+// decimalToRat return an unscaled decimal value divided by 10^scale.
+func decimalToRat(unscaled *big.Int, scale int32) *big.Rat {
+	denominator := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	return new(big.Rat).SetFrac(unscaled, denominator)
+}
+
+// timestampToTime return UTC time of a timestamp, without overflow of nanoseconds for dates far from 1970.
+func timestampToTime(v int64, unit arrow.TimeUnit) time.Time {
+	switch unit {
+	case arrow.Second:
+		return time.Unix(v, 0).UTC()
+	case arrow.Millisecond:
+		return time.UnixMilli(v).UTC()
+	case arrow.Microsecond:
+		return time.UnixMicro(v).UTC()
+	default:
+		return time.Unix(0, v).UTC()
+	}
+}
@@ -0,0 +1,390 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/civil"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"github.com/tiketdatarisal/gcp/shared"
+	"google.golang.org/api/iterator"
+	"io"
+	"sync"
+	"time"
+
+	bqstorage "cloud.google.com/go/bigquery/storage/apiv1"
+	"github.com/linkedin/goavro/v2"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// readClient is a lazily initialized Storage Read API client, shared by all copies of a BigQuery client.
+type readClient struct {
+	mutex  sync.Mutex
+	client *bqstorage.BigQueryReadClient
+}
+
+func (r *readClient) close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.client != nil {
+		_ = r.client.Close()
+		r.client = nil
+	}
+}
+
+// getReadClient return Storage Read API client, initialize it when needed.
+func (q BigQuery) getReadClient() (*bqstorage.BigQueryReadClient, error) {
+	if q.readClient == nil {
+		return nil, ErrInitReadClientFailed
+	}
+
+	q.readClient.mutex.Lock()
+	defer q.readClient.mutex.Unlock()
+
+	if q.readClient.client != nil {
+		return q.readClient.client, nil
+	}
+
+	client, err := bqstorage.NewBigQueryReadClient(q.ctx, q.options...)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrInitReadClientFailed, err)
+	}
+
+	q.readClient.client = client
+	return client, nil
+}
+
+// ReadTableFunc read rows of a table through BigQuery Storage Read API and process them in func.
+// Streams are read in parallel, but func is called from one goroutine at a time and rows are not ordered.
+// At most BufferSize decoded rows, besides a response being decoded by each stream, are held in memory,
+// reading waits until func has processed them.
+// Use Columns and RowRestriction from config to read only part of the table, and Format to transfer rows as Arrow.
+// Reading stops without error when the function return iterator.Done.
+func (q BigQuery) ReadTableFunc(datasetID, tableID string, f func(row map[string]bigquery.Value) error, cfg ...config.ReadConfig) error {
+	if datasetID == "" || tableID == "" || f == nil {
+		return nil
+	}
+
+	// Get config from parameter
+	c := config.InitReadConfig(cfg...)
+
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	return q.readTable(ctx, q.client.Dataset(datasetID).Table(tableID), f, c)
+}
+
+// ReadQueryFunc run a query within client budget, then read its result through BigQuery Storage Read API
+// and process it in func. See ReadTableFunc for how rows are delivered.
+func (q BigQuery) ReadQueryFunc(query string, f func(row map[string]bigquery.Value) error, cfg ...config.ReadConfig) error {
+	if query == "" || f == nil {
+		return nil
+	}
+
+	// Get config from parameter
+	c := config.InitReadConfig(cfg...)
+
+	// Initialize context with timeout when possible
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	task := q.newQuery(query, c.Parameters, c.Labels)
+	job, err := q.runQueryJob(ctx, task, config.RunQueryConfig{MaxBytesBilled: c.MaxBytesBilled}, q.retryPolicy(shared.RetryPolicy{}, c.Retry, c.Delay))
+	if err != nil {
+		return err
+	}
+
+	tmpTable, err := queryDestination(job)
+	if err != nil {
+		return err
+	}
+
+	return q.readTable(ctx, tmpTable, f, c)
+}
+
+// readTable create a read session of a table and read its streams in parallel.
+func (q BigQuery) readTable(ctx context.Context, table *bigquery.Table, f func(row map[string]bigquery.Value) error, c config.ReadConfig) error {
+	meta, err := table.Metadata(ctx)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrReadFailed, err)
+	}
+
+	client, err := q.getReadClient()
+	if err != nil {
+		return err
+	}
+
+	dataFormat := storagepb.DataFormat_AVRO
+	switch c.Format {
+	case bigquery.Avro:
+	case config.Arrow:
+		dataFormat = storagepb.DataFormat_ARROW
+	default:
+		return fmt.Errorf("%w: unsupported format %s", ErrReadFailed, c.Format)
+	}
+
+	readSession := &storagepb.ReadSession{
+		Table:      fmt.Sprintf("projects/%s/datasets/%s/tables/%s", table.ProjectID, table.DatasetID, table.TableID),
+		DataFormat: dataFormat,
+		ReadOptions: &storagepb.ReadSession_TableReadOptions{
+			SelectedFields: c.Columns,
+			RowRestriction: c.RowRestriction,
+		},
+	}
+
+	if !c.SnapshotTime.IsZero() {
+		readSession.TableModifiers = &storagepb.ReadSession_TableModifiers{SnapshotTime: timestamppb.New(c.SnapshotTime)}
+	}
+
+	session, err := client.CreateReadSession(ctx, &storagepb.CreateReadSessionRequest{
+		Parent:         fmt.Sprintf("projects/%s", q.projectID),
+		ReadSession:    readSession,
+		MaxStreamCount: int32(c.MaxStreams),
+	})
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrReadFailed, err)
+	}
+
+	// Session without streams means there is no row to read
+	if len(session.GetStreams()) == 0 {
+		return nil
+	}
+
+	decoder, err := newRowDecoder(session, meta.Schema)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrReadFailed, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Decoded rows are buffered in a bounded channel, stream readers wait when it is full
+	rows := make(chan map[string]bigquery.Value, c.BufferSize)
+	policy := q.retryPolicy(shared.RetryPolicy{}, c.Retry, c.Delay)
+	group, groupCtx := errgroup.WithContext(ctx)
+	for _, stream := range session.GetStreams() {
+		streamName := stream.GetName()
+		group.Go(func() error {
+			return readStream(groupCtx, client, streamName, decoder, rows, policy)
+		})
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := group.Wait()
+		close(rows)
+		done <- err
+	}()
+
+	var funcErr error
+	for row := range rows {
+		if funcErr = f(row); funcErr != nil {
+			break
+		}
+	}
+
+	// Stop stream readers when func has stopped early
+	cancel()
+	streamErr := <-done
+
+	if funcErr == iterator.Done {
+		return nil
+	}
+
+	if funcErr != nil {
+		return fmt.Errorf(errorWrapper, ErrReadFailed, funcErr)
+	}
+
+	if streamErr != nil {
+		return fmt.Errorf(errorWrapper, ErrReadFailed, streamErr)
+	}
+
+	return nil
+}
+
+// readStream read rows of a stream and send them into rows channel.
+// A failed stream is retried using retry policy, resumed from offset of the next row.
+func readStream(ctx context.Context, client *bqstorage.BigQueryReadClient, streamName string, decoder rowDecoder, rows chan<- map[string]bigquery.Value, policy shared.RetryPolicy) error {
+	var offset int64
+	return policy.Do(ctx, func() error {
+		stream, err := client.ReadRows(ctx, &storagepb.ReadRowsRequest{ReadStream: streamName, Offset: offset})
+		if err != nil {
+			return err
+		}
+
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			decoded, err := decoder.decode(res)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range decoded {
+				select {
+				case rows <- row:
+					offset++
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	})
+}
+
+// rowDecoder decode rows of a ReadRows response, safe for concurrent use by stream readers.
+type rowDecoder interface {
+	decode(res *storagepb.ReadRowsResponse) ([]map[string]bigquery.Value, error)
+}
+
+// newRowDecoder return a decoder of the data format of a read session.
+func newRowDecoder(session *storagepb.ReadSession, schema bigquery.Schema) (rowDecoder, error) {
+	if session.GetDataFormat() == storagepb.DataFormat_ARROW {
+		return newArrowDecoder(session.GetArrowSchema().GetSerializedSchema(), schema)
+	}
+
+	codec, err := goavro.NewCodec(session.GetAvroSchema().GetSchema())
+	if err != nil {
+		return nil, err
+	}
+
+	return &avroDecoder{codec: codec, schema: schema}, nil
+}
+
+// avroDecoder decode rows serialized as Avro.
+type avroDecoder struct {
+	codec  *goavro.Codec
+	schema bigquery.Schema
+}
+
+func (d *avroDecoder) decode(res *storagepb.ReadRowsResponse) ([]map[string]bigquery.Value, error) {
+	var rows []map[string]bigquery.Value
+	data := res.GetAvroRows().GetSerializedBinaryRows()
+	for len(data) > 0 {
+		var native any
+		var err error
+		native, data, err = d.codec.NativeFromBinary(data)
+		if err != nil {
+			return nil, err
+		}
+
+		row, err := avroToRow(native, d.schema)
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// avroToRow convert a decoded Avro record into a row, using the same value types as RunQuery.
+// Columns that are not read are omitted from the row.
+func avroToRow(native any, schema bigquery.Schema) (map[string]bigquery.Value, error) {
+	record, ok := native.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected record, got %T", native)
+	}
+
+	row := make(map[string]bigquery.Value, len(record))
+	for _, field := range schema {
+		v, exists := record[field.Name]
+		if !exists {
+			continue
+		}
+
+		value, err := avroToValue(v, field)
+		if err != nil {
+			return nil, err
+		}
+
+		row[field.Name] = value
+	}
+
+	return row, nil
+}
+
+// avroToValue convert a decoded Avro value of a field into a row value.
+func avroToValue(v any, field *bigquery.FieldSchema) (bigquery.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if field.Repeated {
+		items, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected array, got %T", field.Name, v)
+		}
+
+		element := *field
+		element.Repeated = false
+		element.Required = true
+
+		values := make([]bigquery.Value, 0, len(items))
+		for _, item := range items {
+			value, err := avroToValue(item, &element)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return values, nil
+	}
+
+	// NULLABLE field is decoded as a union keyed by type name
+	if !field.Required {
+		if union, ok := v.(map[string]any); ok && len(union) == 1 {
+			for _, inner := range union {
+				v = inner
+			}
+		}
+
+		if v == nil {
+			return nil, nil
+		}
+	}
+
+	switch field.Type {
+	case bigquery.RecordFieldType:
+		return avroToRow(v, field.Schema)
+	case bigquery.TimestampFieldType:
+		if t, ok := v.(time.Time); ok {
+			return t.UTC(), nil
+		}
+	case bigquery.DateFieldType:
+		if t, ok := v.(time.Time); ok {
+			return civil.DateOf(t.UTC()), nil
+		}
+	case bigquery.TimeFieldType:
+		if d, ok := v.(time.Duration); ok {
+			return civil.TimeOf(time.Time{}.Add(d)), nil
+		}
+	case bigquery.DateTimeFieldType:
+		if s, ok := v.(string); ok {
+			dt, err := civil.ParseDateTime(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", field.Name, err)
+			}
+
+			return dt, nil
+		}
+	default:
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("%s: unexpected %T for %s", field.Name, v, field.Type)
+}
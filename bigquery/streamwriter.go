@@ -0,0 +1,273 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	"context"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// writeClient is a lazily initialized Storage Write API client, shared by all copies of a BigQuery client.
+type writeClient struct {
+	mutex  sync.Mutex
+	client *managedwriter.Client
+}
+
+func (w *writeClient) close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.client != nil {
+		_ = w.client.Close()
+		w.client = nil
+	}
+}
+
+// getWriteClient return Storage Write API client, initialize it when needed.
+func (q BigQuery) getWriteClient() (*managedwriter.Client, error) {
+	if q.writeClient == nil {
+		return nil, ErrInitWriteClientFailed
+	}
+
+	q.writeClient.mutex.Lock()
+	defer q.writeClient.mutex.Unlock()
+
+	if q.writeClient.client != nil {
+		return q.writeClient.client, nil
+	}
+
+	client, err := managedwriter.NewClient(q.ctx, q.projectID, q.options...)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrInitWriteClientFailed, err)
+	}
+
+	q.writeClient.client = client
+	return client, nil
+}
+
+// StreamWriter write rows into a table using BigQuery Storage Write API.
+// Rows could be Go structs with `bigquery` tags, maps keyed by column name, or bigquery.ValueSaver.
+type StreamWriter struct {
+	ctx        context.Context
+	config     config.StreamWriterConfig
+	client     *managedwriter.Client
+	stream     *managedwriter.ManagedStream
+	schema     bigquery.Schema
+	descriptor protoreflect.MessageDescriptor
+
+	mutex     sync.Mutex
+	offset    int64
+	finalized bool
+	rowCount  int64
+}
+
+// NewStreamWriter return a new StreamWriter into a table.
+// Protobuf descriptor of rows is derived from the table schema.
+func (q BigQuery) NewStreamWriter(datasetID, tableID string, cfg ...config.StreamWriterConfig) (*StreamWriter, error) {
+	// Get config from parameter
+	c := config.InitStreamWriterConfig(cfg...)
+
+	schema, err := q.GetTableSchema(datasetID, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Derive protobuf descriptor from table schema
+	storageSchema, err := adapt.BQSchemaToStorageTableSchema(schema)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrNewStreamWriterFailed, err)
+	}
+
+	descriptor, err := adapt.StorageSchemaToProto2Descriptor(storageSchema, "root")
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrNewStreamWriterFailed, err)
+	}
+
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf(errorWrapper, ErrNewStreamWriterFailed, "descriptor is not a message descriptor")
+	}
+
+	descriptorProto, err := adapt.NormalizeDescriptor(messageDescriptor)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrNewStreamWriterFailed, err)
+	}
+
+	client, err := q.getWriteClient()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := client.NewManagedStream(q.ctx,
+		managedwriter.WithDestinationTable(managedwriter.TableParentFromParts(q.projectID, datasetID, tableID)),
+		managedwriter.WithType(c.Type),
+		managedwriter.WithSchemaDescriptor(descriptorProto),
+	)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrNewStreamWriterFailed, err)
+	}
+
+	return &StreamWriter{
+		ctx:        q.ctx,
+		config:     c,
+		client:     client,
+		stream:     stream,
+		schema:     schema,
+		descriptor: messageDescriptor,
+	}, nil
+}
+
+// StreamName return name of the write stream.
+func (w *StreamWriter) StreamName() string {
+	return w.stream.StreamName()
+}
+
+// Offset return offset of the next appended row.
+func (w *StreamWriter) Offset() int64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.offset
+}
+
+// Append append rows into the write stream and wait until acknowledged.
+// Return offset of the first appended row, when UseOffsets is set rows are appended exactly once at that offset.
+func (w *StreamWriter) Append(rows ...any) (int64, error) {
+	if len(rows) == 0 {
+		return w.Offset(), nil
+	}
+
+	data := make([][]byte, 0, len(rows))
+	for _, row := range rows {
+		values, err := rowToValues(row, w.schema)
+		if err != nil {
+			return -1, fmt.Errorf(errorWrapper, ErrAppendRowsFailed, err)
+		}
+
+		msg, err := valuesToMessage(values, w.schema, w.descriptor)
+		if err != nil {
+			return -1, fmt.Errorf(errorWrapper, ErrAppendRowsFailed, err)
+		}
+
+		b, err := proto.Marshal(msg)
+		if err != nil {
+			return -1, fmt.Errorf(errorWrapper, ErrAppendRowsFailed, err)
+		}
+
+		data = append(data, b)
+	}
+
+	// Offsets must be sequential, serialize appends
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(w.ctx, w.config.Timeout)
+	defer cancel()
+
+	var opts []managedwriter.AppendOption
+	if w.config.UseOffsets {
+		opts = append(opts, managedwriter.WithOffset(w.offset))
+	}
+
+	result, err := w.stream.AppendRows(ctx, data, opts...)
+	if err != nil {
+		return -1, fmt.Errorf(errorWrapper, ErrAppendRowsFailed, err)
+	}
+
+	offset, err := result.GetResult(ctx)
+	if err != nil {
+		return -1, fmt.Errorf(errorWrapper, ErrAppendRowsFailed, err)
+	}
+
+	if offset < 0 {
+		offset = w.offset
+	}
+
+	w.offset = offset + int64(len(data))
+	return offset, nil
+}
+
+// Finalize mark the write stream as finalized, no more rows could be appended.
+// Required before pending streams are committed. Return number of rows in the stream.
+// Finalizing an already finalized stream return the same number of rows.
+func (w *StreamWriter) Finalize() (int64, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.config.Type == managedwriter.DefaultStream {
+		return w.offset, nil
+	}
+
+	if w.finalized {
+		return w.rowCount, nil
+	}
+
+	rowCount, err := w.stream.Finalize(w.ctx)
+	if err != nil {
+		return -1, fmt.Errorf(errorWrapper, ErrFinalizeStreamFailed, err)
+	}
+
+	w.finalized = true
+	w.rowCount = rowCount
+	return rowCount, nil
+}
+
+// Close closes the write stream.
+func (w *StreamWriter) Close() error {
+	return w.stream.Close()
+}
+
+// CommitStreams finalize and atomically commit pending streams of the same table.
+// Rows in the streams become visible together, or not at all.
+func (q BigQuery) CommitStreams(writers ...*StreamWriter) error {
+	if len(writers) == 0 {
+		return nil
+	}
+
+	client, err := q.getWriteClient()
+	if err != nil {
+		return err
+	}
+
+	// Validate all streams before any of them is finalized
+	parent := managedwriter.TableParentFromStreamName(writers[0].StreamName())
+	for _, w := range writers {
+		if w.config.Type != managedwriter.PendingStream {
+			return fmt.Errorf(errorWrapper, ErrCommitStreamsFailed, "only pending streams could be committed")
+		}
+
+		if managedwriter.TableParentFromStreamName(w.StreamName()) != parent {
+			return fmt.Errorf(errorWrapper, ErrCommitStreamsFailed, "streams must belong to the same table")
+		}
+	}
+
+	var streamNames []string
+	for _, w := range writers {
+		if _, err := w.Finalize(); err != nil {
+			return err
+		}
+
+		streamNames = append(streamNames, w.StreamName())
+	}
+
+	res, err := client.BatchCommitWriteStreams(q.ctx, &storagepb.BatchCommitWriteStreamsRequest{
+		Parent:       parent,
+		WriteStreams: streamNames,
+	})
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrCommitStreamsFailed, err)
+	}
+
+	if len(res.GetStreamErrors()) > 0 {
+		return fmt.Errorf(errorWrapper, ErrCommitStreamsFailed, res.GetStreamErrors()[0].GetErrorMessage())
+	}
+
+	return nil
+}
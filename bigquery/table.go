@@ -0,0 +1,52 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"time"
+)
+
+// CreateTableFromStruct create a new table with a schema inferred from T and options.
+// T must be a struct, its fields are mapped to columns using `bigquery` tags.
+func CreateTableFromStruct[T any](q *BigQuery, datasetID, tableID string, opts ...config.TableOptions) error {
+	var value T
+	schema, err := bigquery.InferSchema(value)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrCreateTableFailed, err)
+	}
+
+	return q.CreateTable(datasetID, tableID, &schema, opts...)
+}
+
+// newTableMetadata return table metadata with partitioning, clustering, expiration, description and labels
+// from options when possible.
+func newTableMetadata(opts ...config.TableOptions) *bigquery.TableMetadata {
+	meta := &bigquery.TableMetadata{}
+	if len(opts) == 0 {
+		return meta
+	}
+
+	o := opts[0]
+	if o.TimePartitioning != nil {
+		meta.TimePartitioning = o.TimePartitioning
+	} else if o.RangePartitioning != nil {
+		meta.RangePartitioning = o.RangePartitioning
+	}
+
+	meta.RequirePartitionFilter = o.RequirePartitionFilter
+	if len(o.ClusteringFields) > 0 {
+		meta.Clustering = &bigquery.Clustering{Fields: o.ClusteringFields}
+	}
+
+	if o.Expiration > 0 {
+		meta.ExpirationTime = time.Now().Add(o.Expiration)
+	}
+
+	meta.Description = o.Description
+	if o.Labels != nil {
+		meta.Labels = o.Labels
+	}
+
+	return meta
+}
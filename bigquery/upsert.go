@@ -0,0 +1,171 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"errors"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"github.com/tiketdatarisal/gcp/shared"
+	"google.golang.org/api/googleapi"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// UpsertResult is number of rows changed by UpsertRows.
+type UpsertResult struct {
+	JobID        string
+	InsertedRows int64
+	UpdatedRows  int64
+	DeletedRows  int64
+}
+
+// UpsertRows insert or update rows of a table by key columns.
+// Rows are inserted into an auto-expiring staging table with the table schema, then merged into the table.
+// The staging table is always deleted. Rows must be unique by key columns, NULL keys are matched with NULL.
+func (q BigQuery) UpsertRows(datasetID, tableID string, keyColumns []string, rows []bigquery.ValueSaver, cfg ...config.UpsertConfig) (*UpsertResult, error) {
+	if datasetID == "" || tableID == "" || len(rows) == 0 {
+		return nil, nil
+	}
+
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf(errorWrapper, ErrUpsertFailed, "key columns are required")
+	}
+
+	// Get config from parameter
+	c := config.InitUpsertConfig(cfg...)
+
+	schema, err := q.GetTableSchema(datasetID, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create staging table, it expires by itself when it could not be deleted
+	stagingDatasetID := c.StagingDatasetID
+	if stagingDatasetID == "" {
+		stagingDatasetID = datasetID
+	}
+
+	stagingTableID := fmt.Sprintf("%s_staging_%d", tableID, time.Now().UnixNano())
+	query, err := newMergeQuery(q.tableRef(datasetID, tableID), q.tableRef(stagingDatasetID, stagingTableID), schema, keyColumns, c)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrUpsertFailed, err)
+	}
+
+	err = q.CreateTable(stagingDatasetID, stagingTableID, &schema, config.TableOptions{Expiration: c.StagingExpiration})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = q.DeleteTable(stagingDatasetID, stagingTableID) }()
+
+	// Newly created table could not be found for a while, retry inserting rows when not found as well
+	policy := q.retryPolicy(shared.RetryPolicy{}, c.Retry, c.Delay)
+	policy.IsRetryable = func(err error) bool {
+		var apiErr *googleapi.Error
+		return shared.IsRetryableError(err) || (errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound)
+	}
+
+	// Rows are inserted with fixed insertIDs, so a retried request does not duplicate rows merged into the table
+	inserter := q.client.Dataset(stagingDatasetID).Table(stagingTableID).Inserter()
+	err = putRows(q.ctx, inserter, rows, policy, 0)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrInsertRowFailed, err)
+	}
+
+	ctx, cancel := q.withTimeout(c.Timeout)
+	defer cancel()
+
+	job, err := q.runQueryJob(ctx, q.newQuery(query, nil, c.Labels), config.RunQueryConfig{}, q.retryPolicy(shared.RetryPolicy{}, c.Retry, c.Delay))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UpsertResult{JobID: job.ID()}
+	if status := job.LastStatus(); status != nil && status.Statistics != nil {
+		if queryStats, ok := status.Statistics.Details.(*bigquery.QueryStatistics); ok && queryStats.DMLStats != nil {
+			result.InsertedRows = queryStats.DMLStats.InsertedRowCount
+			result.UpdatedRows = queryStats.DMLStats.UpdatedRowCount
+			result.DeletedRows = queryStats.DMLStats.DeletedRowCount
+		}
+	}
+
+	return result, nil
+}
+
+// newMergeQuery return a MERGE query from source table into target table, both have the same schema.
+func newMergeQuery(target, source string, schema bigquery.Schema, keyColumns []string, c config.UpsertConfig) (string, error) {
+	var conditions []string
+	for _, key := range keyColumns {
+		field := findField(schema, key)
+		if field == nil {
+			return "", fmt.Errorf("key column %s not found", key)
+		}
+
+		conditions = append(conditions, fmt.Sprintf("T.`%s` IS NOT DISTINCT FROM S.`%s`", field.Name, field.Name))
+	}
+
+	updateColumns := c.UpdateColumns
+	if updateColumns == nil {
+		for _, field := range schema {
+			if !containsColumn(keyColumns, field.Name) {
+				updateColumns = append(updateColumns, field.Name)
+			}
+		}
+	}
+
+	var sets []string
+	for _, name := range updateColumns {
+		field := findField(schema, name)
+		if field == nil {
+			return "", fmt.Errorf("update column %s not found", name)
+		}
+
+		sets = append(sets, fmt.Sprintf("`%s` = S.`%s`", field.Name, field.Name))
+	}
+
+	var columns, values []string
+	for _, field := range schema {
+		columns = append(columns, fmt.Sprintf("`%s`", field.Name))
+		values = append(values, fmt.Sprintf("S.`%s`", field.Name))
+	}
+
+	update := !c.DisableUpdate && len(sets) > 0
+	if !update && c.DisableInsert && !c.DeleteWhenNotMatched {
+		return "", errors.New("nothing to merge, update, insert, and delete are disabled")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("MERGE %s T\nUSING %s S\nON ", target, source))
+	sb.WriteString(strings.Join(conditions, " AND "))
+	if update {
+		sb.WriteString("\nWHEN MATCHED THEN UPDATE SET ")
+		sb.WriteString(strings.Join(sets, ", "))
+	}
+
+	if !c.DisableInsert {
+		sb.WriteString(fmt.Sprintf("\nWHEN NOT MATCHED BY TARGET THEN INSERT (%s) VALUES (%s)",
+			strings.Join(columns, ", "), strings.Join(values, ", ")))
+	}
+
+	if c.DeleteWhenNotMatched {
+		sb.WriteString("\nWHEN NOT MATCHED BY SOURCE THEN DELETE")
+	}
+
+	return sb.String(), nil
+}
+
+func containsColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if strings.EqualFold(column, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tableRef return fully qualified and quoted reference of a table in the client project.
+func (q BigQuery) tableRef(datasetID, tableID string) string {
+	return fmt.Sprintf("`%s.%s.%s`", q.projectID, datasetID, tableID)
+}
@@ -0,0 +1,110 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+	"testing"
+)
+
+func TestNewMergeQuery(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType},
+		{Name: "Region", Type: bigquery.StringFieldType},
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "amount", Type: bigquery.FloatFieldType},
+	}
+
+	const (
+		head   = "MERGE `p.d.t` T\nUSING `p.d.s` S\nON T.`id` IS NOT DISTINCT FROM S.`id`"
+		update = "\nWHEN MATCHED THEN UPDATE SET `Region` = S.`Region`, `name` = S.`name`, `amount` = S.`amount`"
+		insert = "\nWHEN NOT MATCHED BY TARGET THEN INSERT (`id`, `Region`, `name`, `amount`) VALUES (S.`id`, S.`Region`, S.`name`, S.`amount`)"
+		remove = "\nWHEN NOT MATCHED BY SOURCE THEN DELETE"
+	)
+
+	tests := []struct {
+		name    string
+		keys    []string
+		config  config.UpsertConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "update and insert",
+			keys: []string{"id"},
+			want: head + update + insert,
+		},
+		{
+			name:   "update, insert, and delete",
+			keys:   []string{"id"},
+			config: config.UpsertConfig{DeleteWhenNotMatched: true},
+			want:   head + update + insert + remove,
+		},
+		{
+			name:   "insert only",
+			keys:   []string{"id"},
+			config: config.UpsertConfig{DisableUpdate: true},
+			want:   head + insert,
+		},
+		{
+			name:   "update only",
+			keys:   []string{"id"},
+			config: config.UpsertConfig{DisableInsert: true},
+			want:   head + update,
+		},
+		{
+			name:   "delete only",
+			keys:   []string{"id"},
+			config: config.UpsertConfig{DisableUpdate: true, DisableInsert: true, DeleteWhenNotMatched: true},
+			want:   head + remove,
+		},
+		{
+			name:    "nothing to merge",
+			keys:    []string{"id"},
+			config:  config.UpsertConfig{DisableUpdate: true, DisableInsert: true},
+			wantErr: true,
+		},
+		{
+			name:    "nothing to update when all columns are keys",
+			keys:    []string{"id", "region", "name", "amount"},
+			config:  config.UpsertConfig{DisableInsert: true},
+			wantErr: true,
+		},
+		{
+			name:   "selected update columns",
+			keys:   []string{"id"},
+			config: config.UpsertConfig{UpdateColumns: []string{"NAME"}, DisableInsert: true},
+			want:   head + "\nWHEN MATCHED THEN UPDATE SET `name` = S.`name`",
+		},
+		{
+			name:   "keys are quoted with their schema names",
+			keys:   []string{"ID", "region"},
+			config: config.UpsertConfig{DisableInsert: true},
+			want: "MERGE `p.d.t` T\nUSING `p.d.s` S\nON T.`id` IS NOT DISTINCT FROM S.`id` AND T.`Region` IS NOT DISTINCT FROM S.`Region`" +
+				"\nWHEN MATCHED THEN UPDATE SET `name` = S.`name`, `amount` = S.`amount`",
+		},
+		{
+			name:    "unknown key column",
+			keys:    []string{"missing"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown update column",
+			keys:    []string{"id"},
+			config:  config.UpsertConfig{UpdateColumns: []string{"missing"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMergeQuery("`p.d.t`", "`p.d.s`", schema, tt.keys, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newMergeQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("newMergeQuery() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
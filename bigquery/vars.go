@@ -9,7 +9,7 @@ import (
 const (
 	timeoutDuration = 30 * time.Second
 	errorWrapper    = "%w: %v"
-	commaDelimiter  = ","
+	dateLayout      = "2006-01-02"
 )
 
 var (
@@ -29,4 +29,47 @@ var (
 	ErrInsertRowFailed          = errors.New("could not insert new row to BigQuery table")
 	ErrDryRunQueryFailed        = errors.New("could not dry run query")
 	ErrRunQueryFailed           = errors.New("could not run query")
+	ErrSubmitQueryFailed        = errors.New("could not submit query job")
+	ErrEmptyQuery               = errors.New("query must not be empty")
+	ErrAttachQueryJobFailed     = errors.New("could not attach to query job")
+	ErrNotQueryJob              = errors.New("job is not a query job")
+	ErrGetQueryJobStatusFailed  = errors.New("could not get query job status")
+	ErrCancelQueryJobFailed     = errors.New("could not cancel query job")
+	ErrWaitQueryJobFailed       = errors.New("could not wait for query job")
+	ErrQueryBudgetExceeded      = errors.New("query exceeds bytes budget")
+	ErrExportFailed             = errors.New("could not export query result")
+	ErrUnsupportedExportFormat  = errors.New("unsupported export format")
+	ErrUnsupportedCompression   = errors.New("unsupported compression")
+	ErrLoadFailed               = errors.New("could not load data into BigQuery table")
+	ErrInserterClosed           = errors.New("could not add rows to closed inserter")
+	ErrInitWriteClientFailed    = errors.New("could not initialize BigQuery Storage Write client")
+	ErrNewStreamWriterFailed    = errors.New("could not create BigQuery write stream")
+	ErrAppendRowsFailed         = errors.New("could not append rows to BigQuery write stream")
+	ErrFinalizeStreamFailed     = errors.New("could not finalize BigQuery write stream")
+	ErrCommitStreamsFailed      = errors.New("could not commit BigQuery write streams")
+	ErrUpdateTableSchemaFailed  = errors.New("could not update BigQuery table schema")
+	ErrIncompatibleSchemaChange = errors.New("incompatible BigQuery schema change")
+	ErrCreateDatasetFailed      = errors.New("could not create BigQuery dataset")
+	ErrDeleteDatasetFailed      = errors.New("could not delete BigQuery dataset")
+	ErrGetDatasetMetadataFailed = errors.New("could not get BigQuery dataset metadata")
+	ErrUpdateDatasetFailed      = errors.New("could not update BigQuery dataset")
+	ErrCreateViewFailed         = errors.New("could not create BigQuery view")
+	ErrUpdateViewFailed         = errors.New("could not update BigQuery view")
+	ErrRefreshViewFailed        = errors.New("could not refresh BigQuery materialized view")
+	ErrGetViewDefinitionFailed  = errors.New("could not get BigQuery view definition")
+	ErrCopyTableFailed          = errors.New("could not copy BigQuery table")
+	ErrSetExpirationFailed      = errors.New("could not set expiration of copied BigQuery table")
+	ErrInvalidTableID           = errors.New("invalid BigQuery table id")
+	ErrInvalidPageToken         = errors.New("invalid query page token")
+	ErrInvalidPageSize          = errors.New("page size must be greater than 0")
+	ErrInvalidPageTokenKey      = errors.New("page token key is required")
+	ErrInitReadClientFailed     = errors.New("could not initialize BigQuery Storage Read client")
+	ErrReadFailed               = errors.New("could not read BigQuery table through Storage Read API")
+	ErrRunScriptFailed          = errors.New("could not run script")
+	ErrEndSessionFailed         = errors.New("could not end BigQuery session")
+	ErrListJobsFailed           = errors.New("could not list BigQuery jobs")
+	ErrDescribeTableFailed      = errors.New("could not describe BigQuery table")
+	ErrUpsertFailed             = errors.New("could not upsert rows to BigQuery table")
+	ErrCheckFailed              = errors.New("data quality check failed")
+	ErrInvalidCheck             = errors.New("invalid data quality check")
 )
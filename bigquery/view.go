@@ -0,0 +1,122 @@
+package bigquery
+
+import (
+	"cloud.google.com/go/bigquery"
+	"fmt"
+	"github.com/tiketdatarisal/gcp/bigquery/config"
+)
+
+// ViewDefinition is definition of a logical or materialized view.
+type ViewDefinition struct {
+	// Type is either bigquery.ViewTable or bigquery.MaterializedView.
+	Type         bigquery.TableType
+	Query        string
+	UseLegacySQL bool
+	Schema       bigquery.Schema
+
+	// MaterializedView contains refresh settings, only available for a materialized view.
+	MaterializedView *bigquery.MaterializedViewDefinition
+}
+
+// CreateView create a new logical view from a standard SQL query.
+func (q BigQuery) CreateView(datasetID, tableID, query string, opts ...config.ViewOptions) error {
+	var o config.TableOptions
+	if len(opts) > 0 {
+		o = config.TableOptions{Expiration: opts[0].Expiration, Description: opts[0].Description, Labels: opts[0].Labels}
+	}
+
+	meta := newTableMetadata(o)
+	meta.ViewQuery = query
+
+	table := q.client.Dataset(datasetID).Table(tableID)
+	if err := table.Create(q.ctx, meta); err != nil {
+		return fmt.Errorf(errorWrapper, ErrCreateViewFailed, err)
+	}
+
+	return nil
+}
+
+// UpdateViewQuery replace query of an existing logical view.
+func (q BigQuery) UpdateViewQuery(datasetID, tableID, query string) error {
+	table := q.client.Dataset(datasetID).Table(tableID)
+	meta, err := table.Metadata(q.ctx)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrUpdateViewFailed, err)
+	}
+
+	if meta.Type != bigquery.ViewTable {
+		return fmt.Errorf(errorWrapper, ErrUpdateViewFailed, "table is not a logical view")
+	}
+
+	_, err = table.Update(q.ctx, bigquery.TableMetadataToUpdate{ViewQuery: query}, meta.ETag)
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrUpdateViewFailed, err)
+	}
+
+	return nil
+}
+
+// CreateMaterializedView create a new materialized view from a standard SQL query.
+func (q BigQuery) CreateMaterializedView(datasetID, tableID, query string, opts ...config.MaterializedViewOptions) error {
+	o := config.InitMaterializedViewOptions(opts...)
+
+	meta := newTableMetadata(o.TableOptions)
+	meta.MaterializedView = &bigquery.MaterializedViewDefinition{
+		Query:           query,
+		EnableRefresh:   !o.DisableRefresh,
+		RefreshInterval: o.RefreshInterval,
+	}
+
+	table := q.client.Dataset(datasetID).Table(tableID)
+	if err := table.Create(q.ctx, meta); err != nil {
+		return fmt.Errorf(errorWrapper, ErrCreateViewFailed, err)
+	}
+
+	return nil
+}
+
+// RefreshMaterializedView trigger a manual refresh of a materialized view and wait until finished.
+func (q BigQuery) RefreshMaterializedView(datasetID, tableID string, labels ...map[string]string) error {
+	query := fmt.Sprintf("CALL BQ.REFRESH_MATERIALIZED_VIEW('%s.%s.%s')", q.client.Project(), datasetID, tableID)
+
+	var c config.RunQueryConfig
+	if len(labels) > 0 {
+		c.Labels = labels[0]
+	}
+
+	if _, err := q.runQueryJob(q.ctx, q.newQuery(query, nil, c.Labels), c, q.retrier.Policy()); err != nil {
+		return fmt.Errorf(errorWrapper, ErrRefreshViewFailed, err)
+	}
+
+	return nil
+}
+
+// GetViewDefinition return definition of a logical or materialized view.
+func (q BigQuery) GetViewDefinition(datasetID, tableID string) (*ViewDefinition, error) {
+	table := q.client.Dataset(datasetID).Table(tableID)
+	meta, err := table.Metadata(q.ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrGetViewDefinitionFailed, err)
+	}
+
+	definition := &ViewDefinition{
+		Type:   meta.Type,
+		Schema: meta.Schema,
+	}
+
+	switch meta.Type {
+	case bigquery.ViewTable:
+		definition.Query = meta.ViewQuery
+		definition.UseLegacySQL = meta.UseLegacySQL
+	case bigquery.MaterializedView:
+		if meta.MaterializedView != nil {
+			definition.Query = meta.MaterializedView.Query
+		}
+
+		definition.MaterializedView = meta.MaterializedView
+	default:
+		return nil, fmt.Errorf(errorWrapper, ErrGetViewDefinitionFailed, "table is not a view")
+	}
+
+	return definition, nil
+}
@@ -13,6 +13,7 @@ type BigTable struct {
 	ctx         context.Context
 	adminClient *bigtable.AdminClient
 	client      *bigtable.Client
+	retrier     *shared.Retrier
 }
 
 // NewBigTable return a new BigTable client.
@@ -44,9 +45,15 @@ func NewBigTable(ctx context.Context, projectID, instance string, credentialFile
 		ctx:         ctx,
 		adminClient: adminClient,
 		client:      client,
+		retrier:     shared.NewRetrier(),
 	}, nil
 }
 
+// SetRetryPolicy set retry policy of Bigtable calls.
+func (t BigTable) SetRetryPolicy(policy shared.RetryPolicy) {
+	t.retrier.SetPolicy(policy)
+}
+
 // Close closes the BigTable client.
 func (t BigTable) Close() {
 	if t.client != nil {
@@ -60,7 +67,12 @@ func (t BigTable) Close() {
 
 // GetTableNames return a list of table names.
 func (t BigTable) GetTableNames() (shared.StringSlice, error) {
-	tableNames, err := t.adminClient.Tables(t.ctx)
+	var tableNames []string
+	err := t.retrier.Do(t.ctx, func() error {
+		var err error
+		tableNames, err = t.adminClient.Tables(t.ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf(errorWrapper, ErrGetTableNamesFailed, err)
 	}
@@ -77,7 +89,9 @@ func (t BigTable) CreateTable(tableName string) error {
 		return nil
 	}
 
-	err = t.adminClient.CreateTable(t.ctx, tableName)
+	err = t.retrier.Do(t.ctx, func() error {
+		return t.adminClient.CreateTable(t.ctx, tableName)
+	})
 	if err != nil {
 		return fmt.Errorf(errorWrapper, ErrCreateTableFailed, err)
 	}
@@ -94,7 +108,9 @@ func (t BigTable) DeleteTable(tableName string) error {
 		return nil
 	}
 
-	err = t.adminClient.DeleteTable(t.ctx, tableName)
+	err = t.retrier.Do(t.ctx, func() error {
+		return t.adminClient.DeleteTable(t.ctx, tableName)
+	})
 	if err != nil {
 		return fmt.Errorf(errorWrapper, ErrDeleteTableFailed, err)
 	}
@@ -104,7 +120,12 @@ func (t BigTable) DeleteTable(tableName string) error {
 
 // GetColumnFamilies return a list of column families from table.
 func (t BigTable) GetColumnFamilies(tableName string) (shared.StringSlice, error) {
-	tableInfo, err := t.adminClient.TableInfo(t.ctx, tableName)
+	var tableInfo *bigtable.TableInfo
+	err := t.retrier.Do(t.ctx, func() error {
+		var err error
+		tableInfo, err = t.adminClient.TableInfo(t.ctx, tableName)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf(errorWrapper, ErrGetFamilyNamesFailed, err)
 	}
@@ -126,7 +147,9 @@ func (t BigTable) CreateColumnFamily(tableName, columnFamilyName string) error {
 		return nil
 	}
 
-	err = t.adminClient.CreateColumnFamily(t.ctx, tableName, columnFamilyName)
+	err = t.retrier.Do(t.ctx, func() error {
+		return t.adminClient.CreateColumnFamily(t.ctx, tableName, columnFamilyName)
+	})
 	if err != nil {
 		return fmt.Errorf(errorWrapper, ErrCreateFamilyNameFailed, err)
 	}
@@ -142,7 +165,10 @@ func (t BigTable) AddRow(tableName, rowKey, columnFamily string, columns ColumnV
 		mutation.Set(columnFamily, columnName, bigtable.Now(), value)
 	}
 
-	err = table.Apply(t.ctx, rowKey, mutation)
+	// Timestamp is set once, so the mutation is idempotent when retried
+	err = t.retrier.Do(t.ctx, func() error {
+		return table.Apply(t.ctx, rowKey, mutation)
+	})
 	if err != nil {
 		return fmt.Errorf(errorWrapper, ErrAddRowFailed, err)
 	}
@@ -162,9 +188,15 @@ func (t BigTable) ReadRow(tableName, rowKey string, filters ...bigtable.Filter)
 			opts = append(opts, bigtable.RowFilter(filter))
 		}
 
-		row, err = table.ReadRow(t.ctx, rowKey, opts...)
+		err = t.retrier.Do(t.ctx, func() error {
+			row, err = table.ReadRow(t.ctx, rowKey, opts...)
+			return err
+		})
 	} else {
-		row, err = table.ReadRow(t.ctx, rowKey)
+		err = t.retrier.Do(t.ctx, func() error {
+			row, err = table.ReadRow(t.ctx, rowKey)
+			return err
+		})
 	}
 
 	if err != nil {
@@ -186,14 +218,20 @@ func (t BigTable) ReadRowsByKeys(tableName string, rowKeys []string, filters ...
 			opts = append(opts, bigtable.RowFilter(filter))
 		}
 
-		err = table.ReadRows(t.ctx, bigtable.RowList(rowKeys), func(row bigtable.Row) bool {
-			rows = append(rows, row)
-			return true
-		}, opts...)
+		err = t.retrier.Do(t.ctx, func() error {
+			rows = nil
+			return table.ReadRows(t.ctx, bigtable.RowList(rowKeys), func(row bigtable.Row) bool {
+				rows = append(rows, row)
+				return true
+			}, opts...)
+		})
 	} else {
-		err = table.ReadRows(t.ctx, bigtable.RowList(rowKeys), func(row bigtable.Row) bool {
-			rows = append(rows, row)
-			return true
+		err = t.retrier.Do(t.ctx, func() error {
+			rows = nil
+			return table.ReadRows(t.ctx, bigtable.RowList(rowKeys), func(row bigtable.Row) bool {
+				rows = append(rows, row)
+				return true
+			})
 		})
 	}
 
@@ -216,14 +254,20 @@ func (t BigTable) ReadRowsByKeyPrefix(tableName string, keyPrefix string, filter
 			opts = append(opts, bigtable.RowFilter(filter))
 		}
 
-		err = table.ReadRows(t.ctx, bigtable.PrefixRange(keyPrefix), func(row bigtable.Row) bool {
-			rows = append(rows, row)
-			return true
-		}, opts...)
+		err = t.retrier.Do(t.ctx, func() error {
+			rows = nil
+			return table.ReadRows(t.ctx, bigtable.PrefixRange(keyPrefix), func(row bigtable.Row) bool {
+				rows = append(rows, row)
+				return true
+			}, opts...)
+		})
 	} else {
-		err = table.ReadRows(t.ctx, bigtable.PrefixRange(keyPrefix), func(row bigtable.Row) bool {
-			rows = append(rows, row)
-			return true
+		err = t.retrier.Do(t.ctx, func() error {
+			rows = nil
+			return table.ReadRows(t.ctx, bigtable.PrefixRange(keyPrefix), func(row bigtable.Row) bool {
+				rows = append(rows, row)
+				return true
+			})
 		})
 	}
 
@@ -246,14 +290,20 @@ func (t BigTable) ReadRowsByKeyRange(tableName string, startKey, endKey string,
 			opts = append(opts, bigtable.RowFilter(filter))
 		}
 
-		err = table.ReadRows(t.ctx, bigtable.NewRange(startKey, endKey), func(row bigtable.Row) bool {
-			rows = append(rows, row)
-			return true
-		}, opts...)
+		err = t.retrier.Do(t.ctx, func() error {
+			rows = nil
+			return table.ReadRows(t.ctx, bigtable.NewRange(startKey, endKey), func(row bigtable.Row) bool {
+				rows = append(rows, row)
+				return true
+			}, opts...)
+		})
 	} else {
-		err = table.ReadRows(t.ctx, bigtable.NewRange(startKey, endKey), func(row bigtable.Row) bool {
-			rows = append(rows, row)
-			return true
+		err = t.retrier.Do(t.ctx, func() error {
+			rows = nil
+			return table.ReadRows(t.ctx, bigtable.NewRange(startKey, endKey), func(row bigtable.Row) bool {
+				rows = append(rows, row)
+				return true
+			})
 		})
 	}
 
@@ -265,6 +315,7 @@ func (t BigTable) ReadRowsByKeyRange(tableName string, startKey, endKey string,
 }
 
 // ReadRows read a number of rows from table.
+// ReadRows is not retried using retry policy, rows could have been processed in func before a failure.
 func (t BigTable) ReadRows(tableName string, f func(row bigtable.Row), count int, rowSetOpt bigtable.RowSet, filters ...bigtable.Filter) error {
 	max := math.MaxInt
 	if count > 0 {
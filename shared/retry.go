@@ -0,0 +1,311 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	RetryPolicyMaxAttempts    = 4
+	RetryPolicyInitialDelay   = 500 * time.Millisecond
+	RetryPolicyMaxDelay       = 30 * time.Second
+	RetryPolicyMultiplier     = 2
+	RetryPolicyJitter         = 0.2
+	RetryPolicyMaxElapsedTime = 0
+)
+
+// retryableReasons is a list of error reasons of Google APIs and BigQuery jobs that could succeed when retried.
+var retryableReasons = map[string]bool{
+	"backendError":          true,
+	"internalError":         true,
+	"jobInternalError":      true,
+	"jobBackendError":       true,
+	"rateLimitExceeded":     true,
+	"jobRateLimitExceeded":  true,
+	"userRateLimitExceeded": true,
+}
+
+// retryClassifiers is a list of classifiers registered using RegisterRetryClassifier.
+var (
+	retryClassifiers      []RetryClassifier
+	retryClassifiersMutex sync.RWMutex
+)
+
+// RetryClassifier classify an error of a specific API, known is false when the error is not recognized.
+type RetryClassifier func(err error) (retryable, known bool)
+
+// RegisterRetryClassifier register a classifier used by IsRetryableError, for example for errors of a client library
+// that is not imported by this package. Classifiers are tried in order of registration.
+func RegisterRetryClassifier(classifier RetryClassifier) {
+	retryClassifiersMutex.Lock()
+	defer retryClassifiersMutex.Unlock()
+
+	retryClassifiers = append(retryClassifiers, classifier)
+}
+
+// IsRetryableReason return true when an error reason of Google APIs or BigQuery jobs could succeed when retried.
+func IsRetryableReason(reason string) bool {
+	return retryableReasons[reason]
+}
+
+// RetryAttempt is a finished attempt of a call, observed using RetryPolicy.OnAttempt.
+type RetryAttempt struct {
+	// Attempt number of the attempt, starting from 1.
+	Attempt int
+
+	// Err error of the attempt, nil when succeeded.
+	Err error
+
+	// Retryable represent whether the error is classified as retryable.
+	Retryable bool
+
+	// Delay duration before the next attempt, 0 when the call will not be retried.
+	Delay time.Duration
+
+	// Elapsed duration since the first attempt started.
+	Elapsed time.Duration
+}
+
+// RetryPolicy is a policy of retrying failed calls with exponential backoff and jitter.
+// Fields with zero value will be filled-in with default values.
+type RetryPolicy struct {
+	// MaxAttempts max number of attempts including the first one (Optional). Have default value of 4.
+	// Set to 1 to disable retry.
+	MaxAttempts int
+
+	// InitialDelay duration taken before the first retry (Optional). Have default value of 500 ms.
+	// Set to a negative value to retry without delay.
+	InitialDelay time.Duration
+
+	// MaxDelay max duration taken before a retry (Optional). Have default value of 30 s.
+	MaxDelay time.Duration
+
+	// Multiplier of delay on each next retry (Optional). Have default value of 2.
+	Multiplier float64
+
+	// Jitter fraction of delay randomly added or subtracted, at most 1 (Optional). Have default value of 0.2.
+	// Set to a negative value to disable jitter.
+	Jitter float64
+
+	// MaxElapsedTime max duration since the first attempt, after which the call is not retried anymore (Optional).
+	// Have default value of 0 (have no limit).
+	MaxElapsedTime time.Duration
+
+	// IsRetryable classify whether an error could succeed when retried (Optional). Have default value of IsRetryableError.
+	IsRetryable func(err error) bool
+
+	// OnAttempt is called after each attempt, for example to log or count retries (Optional).
+	OnAttempt func(attempt RetryAttempt)
+}
+
+// RetryPolicyDefault is an instance of default RetryPolicy.
+// You can use this policy as reference for your own policy.
+var RetryPolicyDefault = RetryPolicy{
+	MaxAttempts:    RetryPolicyMaxAttempts,
+	InitialDelay:   RetryPolicyInitialDelay,
+	MaxDelay:       RetryPolicyMaxDelay,
+	Multiplier:     RetryPolicyMultiplier,
+	Jitter:         RetryPolicyJitter,
+	MaxElapsedTime: RetryPolicyMaxElapsedTime,
+	IsRetryable:    IsRetryableError,
+}
+
+// InitRetryPolicy return an initialized RetryPolicy with filled-in default values.
+// Negative InitialDelay and Jitter are kept, so the policy could be initialized more than once.
+func InitRetryPolicy(policy ...RetryPolicy) RetryPolicy {
+	if len(policy) == 0 {
+		return RetryPolicyDefault
+	}
+
+	p := policy[0]
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = RetryPolicyMaxAttempts
+	}
+
+	if p.InitialDelay == 0 {
+		p.InitialDelay = RetryPolicyInitialDelay
+	}
+
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = RetryPolicyMaxDelay
+	}
+
+	if p.Multiplier < 1 {
+		p.Multiplier = RetryPolicyMultiplier
+	}
+
+	if p.Jitter == 0 || p.Jitter > 1 {
+		p.Jitter = RetryPolicyJitter
+	}
+
+	if p.MaxElapsedTime < 0 {
+		p.MaxElapsedTime = RetryPolicyMaxElapsedTime
+	}
+
+	if p.IsRetryable == nil {
+		p.IsRetryable = IsRetryableError
+	}
+
+	return p
+}
+
+// Do call f until it succeeded, returned a non-retryable error, or the policy is exhausted.
+// Return error of the last attempt.
+func (p RetryPolicy) Do(ctx context.Context, f func() error) error {
+	p = InitRetryPolicy(p)
+
+	start := time.Now()
+	delay := p.InitialDelay
+	if delay < 0 {
+		delay = 0
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := f()
+
+		a := RetryAttempt{Attempt: attempt, Err: err, Elapsed: time.Since(start)}
+		if err != nil && ctx.Err() == nil {
+			a.Retryable = p.IsRetryable(err)
+		}
+
+		retry := a.Retryable && attempt < p.MaxAttempts
+		if retry {
+			a.Delay = p.jitter(delay)
+			if p.MaxElapsedTime > 0 && a.Elapsed+a.Delay > p.MaxElapsedTime {
+				retry = false
+				a.Delay = 0
+			}
+		}
+
+		if p.OnAttempt != nil {
+			p.OnAttempt(a)
+		}
+
+		if !retry {
+			return err
+		}
+
+		timer := time.NewTimer(a.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+
+		delay = time.Duration(float64(delay) * p.Multiplier)
+		if delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+}
+
+// jitter return delay randomly increased or decreased by jitter fraction.
+func (p RetryPolicy) jitter(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+
+	return delay + time.Duration((rand.Float64()*2-1)*p.Jitter*float64(delay))
+}
+
+// IsRetryableError return true when an error could succeed when retried.
+// Rate limits, server errors (5xx), backend and internal errors of Google APIs, unavailable gRPC services,
+// network timeouts, and errors retryable by registered classifiers are retryable. Cancelled contexts are not retryable.
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		if apiErr.Code == http.StatusTooManyRequests || (apiErr.Code >= 500 && apiErr.Code != http.StatusNotImplemented) {
+			return true
+		}
+
+		for _, item := range apiErr.Errors {
+			if retryableReasons[item.Reason] {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	retryClassifiersMutex.RLock()
+	classifiers := retryClassifiers
+	retryClassifiersMutex.RUnlock()
+
+	for _, classify := range classifiers {
+		if retryable, known := classify(err); known {
+			return retryable
+		}
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		switch grpcErr.GRPCStatus().Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.Internal, codes.Aborted:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Retrier keep retry policy of a client, shared by all copies of the client and safe for concurrent use.
+type Retrier struct {
+	mutex  sync.RWMutex
+	policy RetryPolicy
+}
+
+// NewRetrier return a new Retrier with a retry policy, default policy is used when not set.
+func NewRetrier(policy ...RetryPolicy) *Retrier {
+	return &Retrier{policy: InitRetryPolicy(policy...)}
+}
+
+// Policy return the retry policy, default policy when the Retrier is nil.
+func (r *Retrier) Policy() RetryPolicy {
+	if r == nil {
+		return RetryPolicyDefault
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.policy
+}
+
+// SetPolicy replace the retry policy.
+func (r *Retrier) SetPolicy(policy RetryPolicy) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.policy = InitRetryPolicy(policy)
+}
+
+// Do call f using the retry policy.
+func (r *Retrier) Do(ctx context.Context, f func() error) error {
+	return r.Policy().Do(ctx, f)
+}
@@ -0,0 +1,345 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type timeoutError struct{ timeout bool }
+
+func (e timeoutError) Error() string   { return "timeout" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return false }
+
+type classifiedError struct{ retryable bool }
+
+func (e classifiedError) Error() string { return "classified" }
+
+func init() {
+	RegisterRetryClassifier(func(err error) (bool, bool) {
+		var classified classifiedError
+		if errors.As(err, &classified) {
+			return classified.retryable, true
+		}
+
+		return false, false
+	})
+}
+
+func TestIsRetryableError(t *testing.T) {
+	apiError := func(code int, reasons ...string) error {
+		err := &googleapi.Error{Code: code}
+		for _, reason := range reasons {
+			err.Errors = append(err.Errors, googleapi.ErrorItem{Reason: reason})
+		}
+
+		return err
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain error", err: errors.New("failed"), want: false},
+		{name: "context canceled", err: context.Canceled, want: false},
+		{name: "wrapped deadline exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: false},
+		{name: "too many requests", err: apiError(http.StatusTooManyRequests), want: true},
+		{name: "internal server error", err: apiError(http.StatusInternalServerError), want: true},
+		{name: "service unavailable", err: apiError(http.StatusServiceUnavailable), want: true},
+		{name: "not implemented", err: apiError(http.StatusNotImplemented), want: false},
+		{name: "bad request", err: apiError(http.StatusBadRequest), want: false},
+		{name: "not found", err: apiError(http.StatusNotFound), want: false},
+		{name: "forbidden rate limit reason", err: apiError(http.StatusForbidden, "rateLimitExceeded"), want: true},
+		{name: "forbidden quota reason", err: apiError(http.StatusForbidden, "quotaExceeded"), want: false},
+		{name: "bad request backend reason", err: apiError(http.StatusBadRequest, "invalid", "backendError"), want: true},
+		{name: "wrapped api error", err: fmt.Errorf("insert: %w", apiError(http.StatusBadGateway)), want: true},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, "unavailable"), want: true},
+		{name: "grpc resource exhausted", err: status.Error(codes.ResourceExhausted, "exhausted"), want: true},
+		{name: "grpc aborted", err: status.Error(codes.Aborted, "aborted"), want: true},
+		{name: "grpc invalid argument", err: status.Error(codes.InvalidArgument, "invalid"), want: false},
+		{name: "grpc canceled", err: status.Error(codes.Canceled, "canceled"), want: false},
+		{name: "network timeout", err: timeoutError{timeout: true}, want: true},
+		{name: "network error without timeout", err: timeoutError{timeout: false}, want: false},
+		{name: "unexpected EOF", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "EOF", err: io.EOF, want: false},
+		{name: "retryable by classifier", err: fmt.Errorf("job: %w", classifiedError{retryable: true}), want: true},
+		{name: "not retryable by classifier", err: classifiedError{retryable: false}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy []RetryPolicy
+		want   RetryPolicy
+	}{
+		{
+			name: "no policy",
+			want: RetryPolicyDefault,
+		},
+		{
+			name:   "zero value",
+			policy: []RetryPolicy{{}},
+			want:   RetryPolicyDefault,
+		},
+		{
+			name:   "negative values",
+			policy: []RetryPolicy{{MaxAttempts: -1, MaxDelay: -1, Multiplier: -1, MaxElapsedTime: -1}},
+			want:   RetryPolicyDefault,
+		},
+		{
+			name:   "negative delay and jitter are kept",
+			policy: []RetryPolicy{{InitialDelay: -1, Jitter: -1}},
+			want: RetryPolicy{
+				MaxAttempts:    RetryPolicyMaxAttempts,
+				InitialDelay:   -1,
+				MaxDelay:       RetryPolicyMaxDelay,
+				Multiplier:     RetryPolicyMultiplier,
+				Jitter:         -1,
+				MaxElapsedTime: RetryPolicyMaxElapsedTime,
+			},
+		},
+		{
+			name:   "jitter greater than 1",
+			policy: []RetryPolicy{{MaxAttempts: 2, Jitter: 2}},
+			want: RetryPolicy{
+				MaxAttempts:    2,
+				InitialDelay:   RetryPolicyInitialDelay,
+				MaxDelay:       RetryPolicyMaxDelay,
+				Multiplier:     RetryPolicyMultiplier,
+				Jitter:         RetryPolicyJitter,
+				MaxElapsedTime: RetryPolicyMaxElapsedTime,
+			},
+		},
+		{
+			name:   "set values",
+			policy: []RetryPolicy{{MaxAttempts: 1, InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 3, Jitter: 0.5, MaxElapsedTime: time.Hour}},
+			want:   RetryPolicy{MaxAttempts: 1, InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 3, Jitter: 0.5, MaxElapsedTime: time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InitRetryPolicy(tt.policy...)
+			if got.IsRetryable == nil {
+				t.Errorf("InitRetryPolicy().IsRetryable = nil")
+			}
+
+			got.IsRetryable, tt.want.IsRetryable = nil, nil
+			if got.MaxAttempts != tt.want.MaxAttempts || got.InitialDelay != tt.want.InitialDelay ||
+				got.MaxDelay != tt.want.MaxDelay || got.Multiplier != tt.want.Multiplier ||
+				got.Jitter != tt.want.Jitter || got.MaxElapsedTime != tt.want.MaxElapsedTime {
+				t.Errorf("InitRetryPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	retryable := &googleapi.Error{Code: http.StatusServiceUnavailable}
+	permanent := errors.New("permanent")
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{
+			name:         "succeeded at once",
+			policy:       RetryPolicy{InitialDelay: -1},
+			errs:         []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "succeeded after retries",
+			policy:       RetryPolicy{InitialDelay: -1},
+			errs:         []error{retryable, retryable, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "zero value policy retries 4 times in total",
+			policy:       RetryPolicy{InitialDelay: -1},
+			errs:         []error{retryable, retryable, retryable, retryable, nil},
+			wantErr:      retryable,
+			wantAttempts: RetryPolicyMaxAttempts,
+		},
+		{
+			name:         "max attempts",
+			policy:       RetryPolicy{MaxAttempts: 2, InitialDelay: -1},
+			errs:         []error{retryable, retryable, nil},
+			wantErr:      retryable,
+			wantAttempts: 2,
+		},
+		{
+			name:         "retry disabled",
+			policy:       RetryPolicy{MaxAttempts: 1},
+			errs:         []error{retryable, nil},
+			wantErr:      retryable,
+			wantAttempts: 1,
+		},
+		{
+			name:         "permanent error is not retried",
+			policy:       RetryPolicy{InitialDelay: -1},
+			errs:         []error{retryable, permanent, nil},
+			wantErr:      permanent,
+			wantAttempts: 2,
+		},
+		{
+			name:         "custom classifier",
+			policy:       RetryPolicy{InitialDelay: -1, IsRetryable: func(err error) bool { return err == permanent }},
+			errs:         []error{permanent, retryable, nil},
+			wantErr:      retryable,
+			wantAttempts: 2,
+		},
+		{
+			name:         "max elapsed time",
+			policy:       RetryPolicy{InitialDelay: time.Hour, MaxElapsedTime: time.Minute},
+			errs:         []error{retryable, nil},
+			wantErr:      retryable,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []RetryAttempt
+			tt.policy.OnAttempt = func(a RetryAttempt) { attempts = append(attempts, a) }
+
+			calls := 0
+			err := tt.policy.Do(context.Background(), func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if err != tt.wantErr {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if calls != tt.wantAttempts || len(attempts) != tt.wantAttempts {
+				t.Fatalf("Do() called f %d times and OnAttempt %d times, want %d", calls, len(attempts), tt.wantAttempts)
+			}
+
+			for i, a := range attempts {
+				if a.Attempt != i+1 || a.Err != tt.errs[i] {
+					t.Errorf("attempt %d = %+v, want attempt %d with error %v", i, a, i+1, tt.errs[i])
+				}
+
+				last := i == len(attempts)-1
+				if last && a.Delay != 0 {
+					t.Errorf("last attempt delay = %v, want 0", a.Delay)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoBackoff(t *testing.T) {
+	var delays []time.Duration
+	policy := RetryPolicy{
+		MaxAttempts:  5,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     3 * time.Millisecond,
+		Multiplier:   2,
+		Jitter:       -1,
+		OnAttempt:    func(a RetryAttempt) { delays = append(delays, a.Delay) },
+	}
+
+	_ = policy.Do(context.Background(), func() error { return io.ErrUnexpectedEOF })
+
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond, 0}
+	if len(delays) != len(want) {
+		t.Fatalf("delays = %v, want %v", delays, want)
+	}
+
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("delays = %v, want %v", delays, want)
+			break
+		}
+	}
+}
+
+func TestRetryPolicyDoContext(t *testing.T) {
+	t.Run("deadline stops waiting before next attempt", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		calls := 0
+		start := time.Now()
+		err := RetryPolicy{InitialDelay: time.Hour}.Do(ctx, func() error {
+			calls++
+			return io.ErrUnexpectedEOF
+		})
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Do() error = %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+
+		if calls != 1 {
+			t.Errorf("Do() called f %d times, want 1", calls)
+		}
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Do() returned after %v, want about the context deadline", elapsed)
+		}
+	})
+
+	t.Run("error after context is done is not retried", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		calls := 0
+		err := RetryPolicy{InitialDelay: -1}.Do(ctx, func() error {
+			calls++
+			cancel()
+			return io.ErrUnexpectedEOF
+		})
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Do() error = %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+
+		if calls != 1 {
+			t.Errorf("Do() called f %d times, want 1", calls)
+		}
+	})
+}
+
+func TestRetrier(t *testing.T) {
+	var nilRetrier *Retrier
+	if got := nilRetrier.Policy(); got.MaxAttempts != RetryPolicyMaxAttempts {
+		t.Errorf("nil Retrier Policy().MaxAttempts = %d, want %d", got.MaxAttempts, RetryPolicyMaxAttempts)
+	}
+
+	nilRetrier.SetPolicy(RetryPolicy{MaxAttempts: 1})
+
+	r := NewRetrier()
+	r.SetPolicy(RetryPolicy{MaxAttempts: 2, InitialDelay: -1})
+
+	calls := 0
+	_ = r.Do(context.Background(), func() error {
+		calls++
+		return io.ErrUnexpectedEOF
+	})
+	if calls != 2 {
+		t.Errorf("Retrier.Do() called f %d times, want 2", calls)
+	}
+}
@@ -13,8 +13,9 @@ import (
 )
 
 type Storage struct {
-	ctx    context.Context
-	client *storage.Client
+	ctx     context.Context
+	client  *storage.Client
+	retrier *shared.Retrier
 }
 
 // NewStorage return a new Storage client.
@@ -32,11 +33,27 @@ func NewStorage(ctx context.Context, credentialFile ...string) (*Storage, error)
 	}
 
 	return &Storage{
-		ctx:    ctx,
-		client: client,
+		ctx:     ctx,
+		client:  client,
+		retrier: shared.NewRetrier(),
 	}, nil
 }
 
+// SetRetryPolicy set retry policy of Storage calls.
+func (s Storage) SetRetryPolicy(policy shared.RetryPolicy) {
+	s.retrier.SetPolicy(policy)
+}
+
+// retry call f with a timeout context, retried using retry policy when failed with a retryable error.
+func (s Storage) retry(f func(ctx context.Context) error) error {
+	return s.retrier.Do(s.ctx, func() error {
+		ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
+		defer cancel()
+
+		return f(ctx)
+	})
+}
+
 // Close closes the Storage client.
 func (s Storage) Close() {
 	if s.client != nil {
@@ -46,22 +63,25 @@ func (s Storage) Close() {
 
 // GetBucketNames returns a list of bucket names.
 func (s Storage) GetBucketNames(projectID string) (shared.StringSlice, error) {
-	ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
-	defer cancel()
-
-	bucketIterator := s.client.Buckets(ctx, projectID)
 	var bucketNames shared.StringSlice
-	for {
-		bucket, err := bucketIterator.Next()
-		if err == iterator.Done {
-			break
-		}
-
-		if err != nil {
-			return nil, fmt.Errorf(errorWrapper, ErrGetBucketNamesFailed, err)
+	err := s.retry(func(ctx context.Context) error {
+		bucketNames = nil
+		bucketIterator := s.client.Buckets(ctx, projectID)
+		for {
+			bucket, err := bucketIterator.Next()
+			if err == iterator.Done {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			bucketNames = append(bucketNames, bucket.Name)
 		}
-
-		bucketNames = append(bucketNames, bucket.Name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrGetBucketNamesFailed, err)
 	}
 
 	return bucketNames, nil
@@ -69,32 +89,11 @@ func (s Storage) GetBucketNames(projectID string) (shared.StringSlice, error) {
 
 // GetFileNames return list of file names.
 func (s Storage) GetFileNames(bucketName string) (shared.StringSlice, error) {
-	ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
-	defer cancel()
-
-	fileIterator := s.client.Bucket(bucketName).Objects(ctx, nil)
-	var fileNames shared.StringSlice
-	for {
-		file, err := fileIterator.Next()
-		if err == iterator.Done {
-			break
-		}
-
-		if err != nil {
-			return nil, fmt.Errorf(errorWrapper, ErrGetFilenamesFailed, err)
-		}
-
-		fileNames = append(fileNames, file.Name)
-	}
-
-	return fileNames, nil
+	return s.getFileNames(bucketName, nil)
 }
 
 // GetFileNamesWithPrefix return list of file names with prefix.
 func (s Storage) GetFileNamesWithPrefix(bucketName, prefix string, restrictResult bool) (shared.StringSlice, error) {
-	ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
-	defer cancel()
-
 	var query *storage.Query = nil
 	if prefix != "" {
 		query = &storage.Query{Prefix: prefix}
@@ -103,19 +102,30 @@ func (s Storage) GetFileNamesWithPrefix(bucketName, prefix string, restrictResul
 		}
 	}
 
-	fileIterator := s.client.Bucket(bucketName).Objects(ctx, query)
-	var fileNames shared.StringSlice
-	for {
-		file, err := fileIterator.Next()
-		if err == iterator.Done {
-			break
-		}
+	return s.getFileNames(bucketName, query)
+}
 
-		if err != nil {
-			return nil, fmt.Errorf(errorWrapper, ErrGetFilenamesFailed, err)
+// getFileNames return list of file names matching a query, all files when query is nil.
+func (s Storage) getFileNames(bucketName string, query *storage.Query) (shared.StringSlice, error) {
+	var fileNames shared.StringSlice
+	err := s.retry(func(ctx context.Context) error {
+		fileNames = nil
+		fileIterator := s.client.Bucket(bucketName).Objects(ctx, query)
+		for {
+			file, err := fileIterator.Next()
+			if err == iterator.Done {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			fileNames = append(fileNames, file.Name)
 		}
-
-		fileNames = append(fileNames, file.Name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errorWrapper, ErrGetFilenamesFailed, err)
 	}
 
 	return fileNames, nil
@@ -123,7 +133,12 @@ func (s Storage) GetFileNamesWithPrefix(bucketName, prefix string, restrictResul
 
 // FileMimeType return file mime type.
 func (s Storage) FileMimeType(bucketName, fileName string) (string, error) {
-	attr, err := s.client.Bucket(bucketName).Object(fileName).Attrs(s.ctx)
+	var attr *storage.ObjectAttrs
+	err := s.retry(func(ctx context.Context) error {
+		var err error
+		attr, err = s.client.Bucket(bucketName).Object(fileName).Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -142,14 +157,17 @@ func (s Storage) IsFileExists(bucketName, fileName string) error {
 
 // StreamReadFile streams a file for reading.
 func (s Storage) StreamReadFile(bucketName, fileName string, ctx ...context.Context) (io.ReadCloser, error) {
-	var reader *storage.Reader
-	var err error
+	readerCtx := s.ctx
 	if len(ctx) > 0 {
-		reader, err = s.client.Bucket(bucketName).Object(fileName).NewReader(ctx[0])
-	} else {
-		reader, err = s.client.Bucket(bucketName).Object(fileName).NewReader(s.ctx)
+		readerCtx = ctx[0]
 	}
 
+	var reader *storage.Reader
+	err := s.retrier.Do(readerCtx, func() error {
+		var err error
+		reader, err = s.client.Bucket(bucketName).Object(fileName).NewReader(readerCtx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf(errorWrapper, ErrStreamFailed, err)
 	}
@@ -159,16 +177,17 @@ func (s Storage) StreamReadFile(bucketName, fileName string, ctx ...context.Cont
 
 // DownloadFile download a file into byte slice.
 func (s Storage) DownloadFile(bucketName, fileName string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
-	defer cancel()
-
-	reader, err := s.StreamReadFile(bucketName, fileName, ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = reader.Close() }()
+	var data []byte
+	err := s.retry(func(ctx context.Context) error {
+		reader, err := s.client.Bucket(bucketName).Object(fileName).NewReader(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = reader.Close() }()
 
-	data, err := io.ReadAll(reader)
+		data, err = io.ReadAll(reader)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf(errorWrapper, ErrDownloadFailed, err)
 	}
@@ -189,14 +208,17 @@ func (s Storage) StreamWriteFile(bucketName, fileName string, ctx ...context.Con
 }
 
 // UploadFile upload a file to a bucket.
+// The upload is only finished when the writer is closed, so its error is checked as well.
 func (s Storage) UploadFile(bucketName, fileName string, data []byte) error {
-	ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
-	defer cancel()
-
-	writer := s.StreamWriteFile(bucketName, fileName, ctx)
-	defer func() { _ = writer.Close() }()
+	err := s.retry(func(ctx context.Context) error {
+		writer := s.StreamWriteFile(bucketName, fileName, ctx)
+		if _, err := writer.Write(data); err != nil {
+			_ = writer.Close()
+			return err
+		}
 
-	_, err := writer.Write(data)
+		return writer.Close()
+	})
 	if err != nil {
 		return fmt.Errorf(errorWrapper, ErrUploadFailed, err)
 	}
@@ -206,19 +228,32 @@ func (s Storage) UploadFile(bucketName, fileName string, data []byte) error {
 
 // CopyFile copy a file from source to destination.
 func (s Storage) CopyFile(srcBucket, srcFileName, dstBucket, dstFilename string) error {
-	ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
-	defer cancel()
-
 	srcObject := s.client.Bucket(srcBucket).Object(srcFileName)
 	dstObject := s.client.Bucket(dstBucket).Object(dstFilename)
 
-	if _, err := dstObject.CopierFrom(srcObject).Run(ctx); err != nil {
+	err := s.retry(func(ctx context.Context) error {
+		_, err := dstObject.CopierFrom(srcObject).Run(ctx)
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf(errorWrapper, ErrCopyFailed, err)
 	}
 
 	return nil
 }
 
+// DeleteFile delete a file from a bucket.
+func (s Storage) DeleteFile(bucketName, fileName string) error {
+	err := s.retry(func(ctx context.Context) error {
+		return s.client.Bucket(bucketName).Object(fileName).Delete(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf(errorWrapper, ErrDeleteFailed, err)
+	}
+
+	return nil
+}
+
 // CreatePublicURLs returns public urls from specific bucket and filenames.
 func (s Storage) CreatePublicURLs(bucket string, filenames ...string) ([]string, error) {
 	if bucket == "" || len(filenames) == 0 {
@@ -226,13 +261,14 @@ func (s Storage) CreatePublicURLs(bucket string, filenames ...string) ([]string,
 	}
 
 	const prefix = "https://storage.googleapis.com"
-	ctx, cancel := context.WithTimeout(s.ctx, timeoutDuration)
-	defer cancel()
 
 	var urls []string
 	for _, filename := range filenames {
 		acl := s.client.Bucket(bucket).Object(filename).ACL()
-		if err := acl.Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
+		err := s.retry(func(ctx context.Context) error {
+			return acl.Set(ctx, storage.AllUsers, storage.RoleReader)
+		})
+		if err != nil {
 			return nil, err
 		}
 
@@ -23,4 +23,5 @@ var (
 	ErrDownloadFailed          = errors.New("could not download from Storage service")
 	ErrUploadFailed            = errors.New("could not upload to Storage service")
 	ErrCopyFailed              = errors.New("could not copy file")
+	ErrDeleteFailed            = errors.New("could not delete file")
 )